@@ -0,0 +1,69 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bidiControls holds the Unicode bidirectional control characters, which
+// can make source code display differently from how it is scanned
+// (CVE-2021-42574, "Trojan Source").
+const bidiControls = "\u061C\u200E\u200F\u202A\u202B\u202C\u202D\u202E\u2066\u2067\u2068\u2069"
+
+// bidiState holds the configuration of bidirectional control detection.
+type bidiState struct {
+	enabled bool
+	typ     ItemType   // ERROR or WARNING
+	allowed []ItemType // types of items that may contain controls
+}
+
+// DetectBidi makes the scanner report Unicode bidirectional control
+// characters in the input, which can be used to make source code display
+// differently from how it is scanned (CVE-2021-42574, "Trojan Source").
+// Each control character is reported with an item of type t, which must be
+// ERROR or WARNING, before the item containing it. Controls are permitted
+// in items and trivia of the allowed types, such as string literals; input
+// skipped with Ignore is always checked.
+func DetectBidi(t ItemType, allowed ...ItemType) Option {
+	if t != ERROR && t != WARNING {
+		panic("scan: DetectBidi type must be ERROR or WARNING")
+	}
+	return func(s *Scanner) {
+		s.bidi = bidiState{enabled: true, typ: t, allowed: allowed}
+	}
+}
+
+// bidiAllowed reports whether items of type t may contain bidirectional
+// control characters.
+func (s *Scanner) bidiAllowed(t ItemType) bool {
+	for _, a := range s.bidi.allowed {
+		if t == a {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBidi reports the bidirectional control characters in text, which
+// starts at position p.
+func (s *Scanner) checkBidi(p Pos, text string) {
+	if !strings.ContainsAny(text, bidiControls) {
+		return
+	}
+	for i, r := range text {
+		if !strings.ContainsRune(bidiControls, r) {
+			continue
+		}
+		q := p + Pos(i)
+		err := fmt.Errorf("bidirectional control character %U at %s", r, s.lineCol(q))
+		if s.bidi.typ == WARNING {
+			s.emit(Item{Typ: WARNING, Pos: q, Val: err.Error(), err: err})
+		} else {
+			s.errorAt(q, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+// lexBidi scans identifiers and "#" line comments separated by spaces.
+func lexBidi(s *Scanner) StateFn {
+	switch {
+	case s.SkipSpace(AllSpace):
+	case s.SkipLineComment("#"):
+	case s.Peek() == EOF:
+		s.Emit(EOF)
+		return nil
+	default:
+		for r := s.Next(); r != ' ' && r != EOF; r = s.Next() {
+		}
+		s.Backup()
+		s.Emit(IDENTIFIER)
+	}
+	return lexBidi
+}
+
+type bidiTest struct {
+	name  string
+	opts  []Option
+	items []Item
+}
+
+const bidiInput = "a\u202Eb # c\u2066"
+
+var bidiTests = []bidiTest{
+	{"off", nil, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a\u202Eb"},
+		{Typ: EOF, Pos: 12, Val: ""},
+	}},
+	{"error", []Option{DetectBidi(ERROR)}, []Item{
+		{Typ: ERROR, Pos: 1, Val: "bidirectional control character U+202E at 1:2"},
+		{Typ: IDENTIFIER, Pos: 0, Val: "a\u202Eb"},
+		{Typ: ERROR, Pos: 9, Val: "bidirectional control character U+2066 at 1:8"},
+		{Typ: EOF, Pos: 12, Val: ""},
+	}},
+	{"warning", []Option{DetectBidi(WARNING, IDENTIFIER)}, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a\u202Eb"},
+		{Typ: WARNING, Pos: 9, Val: "bidirectional control character U+2066 at 1:8"},
+		{Typ: EOF, Pos: 12, Val: ""},
+	}},
+	{"allowed in comments", []Option{DetectBidi(ERROR, COMMENT), CollectTrivia(COMMENT)}, []Item{
+		{Typ: ERROR, Pos: 1, Val: "bidirectional control character U+202E at 1:2"},
+		{Typ: IDENTIFIER, Pos: 0, Val: "a\u202Eb"},
+		{Typ: EOF, Pos: 12, Val: ""},
+	}},
+}
+
+func TestDetectBidi(t *testing.T) {
+	for _, test := range bidiTests {
+		items := drain(New(test.name, bidiInput, lexBidi, test.opts...))
+		if !equal(items, test.items, true) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+func TestDetectBidiSubScan(t *testing.T) {
+	// lexOuter sub-scans the whole input and then jumps over it.
+	lexOuter := func(s *Scanner) StateFn {
+		end := Pos(len(bidiInput))
+		s.SubScan(0, end, lexBidi)
+		s.Seek(end)
+		s.Emit(EOF)
+		return nil
+	}
+	items := drain(New("sub-scan", bidiInput, lexOuter, DetectBidi(ERROR, COMMENT), CollectTrivia(COMMENT)))
+	expected := bidiTests[3].items
+	if !equal(items, expected, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrStale is returned by DecodeItems if the items were scanned from a
+// different input.
+var ErrStale = errors.New("scan: cached items were scanned from a different input")
+
+// binaryMagic starts the binary encoding of items.
+const binaryMagic = "scan\x01"
+
+// Flags stored with each item in the binary encoding.
+const (
+	valFromInput = 0 // the value is the text of the input at the item's position
+	valLiteral   = 1 // the value is stored
+	valHasEnd    = 2 // the end of the item, relative to its position, follows
+)
+
+// errCorrupt is returned by DecodeItems for malformed encodings.
+var errCorrupt = errors.New("scan: corrupt binary item encoding")
+
+// maxPrealloc bounds the number of items DecodeItems allocates before
+// reading them, since the count is not trusted.
+const maxPrealloc = 1 << 12
+
+// EncodeItems writes items, which were scanned from input, to w in a
+// compact binary format meant for caching the results of lexing on disk.
+// The encoding records a hash of the input instead of the input itself,
+// and the values of items are only stored if they differ from the text of
+// the input at their position. Ends that differ from Pos+len(Val), such
+// as those of items emitted by EmitTrimmed, are preserved, but the results
+// of Value and LeadingTrivia are not.
+func EncodeItems(w io.Writer, input string, items []Item) error {
+	bw := bufio.NewWriter(w)
+	sum := sha256.Sum256([]byte(input))
+	bw.WriteString(binaryMagic)
+	bw.Write(sum[:])
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(x uint64) { bw.Write(buf[:binary.PutUvarint(buf[:], x)]) }
+	putVarint := func(x int64) { bw.Write(buf[:binary.PutVarint(buf[:], x)]) }
+	putUvarint(uint64(len(items)))
+	var prev Pos
+	for _, item := range items {
+		putVarint(int64(item.Typ))
+		putVarint(int64(item.Pos - prev))
+		prev = item.Pos
+		putUvarint(uint64(len(item.Val)))
+		var flag byte = valFromInput
+		end := int(item.Pos) + len(item.Val)
+		if item.Pos < 0 || end > len(input) || input[item.Pos:end] != item.Val {
+			flag |= valLiteral
+		}
+		if item.end != 0 {
+			flag |= valHasEnd
+		}
+		bw.WriteByte(flag)
+		if flag&valHasEnd != 0 {
+			putVarint(int64(item.End() - item.Pos))
+		}
+		if flag&valLiteral != 0 {
+			bw.WriteString(item.Val)
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeItems reads items written by EncodeItems for the same input. If
+// the items were scanned from a different input, it returns ErrStale.
+func DecodeItems(r io.Reader, input string) ([]Item, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(binaryMagic)+sha256.Size)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("scan: reading cached items: %w", err)
+	}
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return nil, errors.New("scan: not a binary item encoding")
+	}
+	sum := sha256.Sum256([]byte(input))
+	if !bytes.Equal(header[len(binaryMagic):], sum[:]) {
+		return nil, ErrStale
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("scan: reading cached items: %w", err)
+	}
+	items := make([]Item, 0, min(n, maxPrealloc))
+	var prev Pos
+	for ; n > 0; n-- {
+		typ, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("scan: reading cached items: %w", err)
+		}
+		delta, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("scan: reading cached items: %w", err)
+		}
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("scan: reading cached items: %w", err)
+		}
+		flag, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("scan: reading cached items: %w", err)
+		}
+		if flag&^(valLiteral|valHasEnd) != 0 {
+			return nil, errCorrupt
+		}
+		item := Item{Typ: ItemType(typ), Pos: prev + Pos(delta)}
+		prev = item.Pos
+		var width int64
+		if flag&valHasEnd != 0 {
+			if width, err = binary.ReadVarint(br); err != nil {
+				return nil, fmt.Errorf("scan: reading cached items: %w", err)
+			}
+		}
+		if flag&valLiteral != 0 {
+			// Read the value as it arrives instead of trusting size.
+			val, err := io.ReadAll(io.LimitReader(br, int64(min(size, 1<<62))))
+			if err != nil {
+				return nil, fmt.Errorf("scan: reading cached items: %w", err)
+			}
+			if uint64(len(val)) != size {
+				return nil, errCorrupt
+			}
+			item.Val = string(val)
+		} else {
+			if item.Pos < 0 || int(item.Pos) > len(input) || size > uint64(len(input)-int(item.Pos)) {
+				return nil, errCorrupt
+			}
+			item.Val = input[item.Pos : int(item.Pos)+int(size)]
+		}
+		if flag&valHasEnd != 0 {
+			if width < 0 || item.Pos+Pos(width) < item.Pos {
+				return nil, errCorrupt
+			}
+			item.end = item.Pos + Pos(width) + 1
+		}
+		if item.Typ == ERROR || item.Typ == WARNING {
+			item.err = errors.New(item.Val)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
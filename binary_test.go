@@ -0,0 +1,73 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBinaryEncoding(t *testing.T) {
+	input := "(12 + ab) - ?"
+	items := collect(&lexTest{"binary", input, nil}, "", "")
+	var b bytes.Buffer
+	if err := EncodeItems(&b, input, items); err != nil {
+		t.Fatal(err)
+	}
+	// Only the value of the error item is stored.
+	if b.Len() > len(binaryMagic)+32+1+len(items)*4+len("lex error") {
+		t.Errorf("encoding of %d items takes %d bytes", len(items), b.Len())
+	}
+	decoded, err := DecodeItems(bytes.NewReader(b.Bytes()), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(decoded, items, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", decoded, items)
+	}
+	if _, err := DecodeItems(bytes.NewReader(b.Bytes()), input+" "); err != ErrStale {
+		t.Errorf("got error %v for a changed input, expected ErrStale", err)
+	}
+	if _, err := DecodeItems(bytes.NewReader(b.Bytes()[:b.Len()-1]), input); err == nil {
+		t.Errorf("decoded a truncated encoding")
+	}
+}
+
+func TestBinaryEncodingEnd(t *testing.T) {
+	input := `"a b" x`
+	items := []Item{
+		Item{Typ: IDENTIFIER, Pos: 1, Val: "a b"}.withEnd(5),
+		{Typ: IDENTIFIER, Pos: 6, Val: "X"},
+		{Typ: EOF, Pos: 7},
+	}
+	var b bytes.Buffer
+	if err := EncodeItems(&b, input, items); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeItems(bytes.NewReader(b.Bytes()), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, items) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", decoded, items)
+	}
+}
+
+func TestBinaryEncodingCorrupt(t *testing.T) {
+	header := binaryMagic + string(func() []byte { s := sha256.Sum256(nil); return s[:] }())
+	for _, body := range []string{
+		"\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01",                 // a huge item count
+		"\x01\x02\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01\x01", // a huge literal value
+		"\x01\x02\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01\x00", // a huge value from the input
+		"\x01\x02\x00\x00\x04",                                     // an unknown flag
+	} {
+		if _, err := DecodeItems(strings.NewReader(header+body), ""); err == nil {
+			t.Errorf("%q: decoded a corrupt encoding", body)
+		}
+	}
+}
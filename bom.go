@@ -0,0 +1,51 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bom is the Unicode byte order mark, which some editors put at the start
+// of UTF-8 files.
+const bom = '\uFEFF'
+
+// SkipBOM makes the scanner skip a UTF-8 byte order mark at the start of
+// the input, as saved by some Windows editors, so that lexers need not
+// handle it. With the CollectTrivia option, the mark is recorded as trivia
+// of the first item. A byte order mark elsewhere in the input is passed to
+// the lexer as usual, but also reported with an error item when it is
+// first read with Next.
+func SkipBOM() Option {
+	return func(s *Scanner) {
+		s.skipBOM = true
+	}
+}
+
+// skipLeadingBOM skips a byte order mark at the start of the input.
+func (s *Scanner) skipLeadingBOM() {
+	if !strings.HasPrefix(s.input, string(bom)) {
+		return
+	}
+	s.pos += Pos(len(string(bom)))
+	s.bomSeen = s.pos
+	if s.trivia.enabled {
+		s.addTrivia(s.trivia.typ)
+	}
+	s.start = s.pos
+	s.covered = s.pos
+}
+
+// unexpectedBOM reports the byte order mark just read by Next, unless it
+// has been reported before.
+func (s *Scanner) unexpectedBOM() {
+	if s.pos <= s.bomSeen {
+		return
+	}
+	s.bomSeen = s.pos
+	p := s.pos - s.width
+	s.errorAt(p, fmt.Errorf("unexpected byte order mark at %s", s.lineCol(p)))
+}
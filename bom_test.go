@@ -0,0 +1,56 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+type bomTest struct {
+	name  string
+	input string
+	opts  []Option
+	items []Item
+}
+
+var bomTests = []bomTest{
+	{"no option", "\uFEFFa", nil, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "\uFEFFa"},
+		{Typ: EOF, Pos: 4, Val: ""},
+	}},
+	{"leading", "\uFEFFa b", []Option{SkipBOM()}, []Item{
+		{Typ: IDENTIFIER, Pos: 3, Val: "a"},
+		{Typ: SPACE, Pos: 4, Val: " "},
+		{Typ: IDENTIFIER, Pos: 5, Val: "b"},
+		{Typ: EOF, Pos: 6, Val: ""},
+	}},
+	{"mid-file", "a \uFEFFb", []Option{SkipBOM()}, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: ERROR, Pos: 2, Val: "unexpected byte order mark at 1:3"}, // peeked by SkipSpace
+		{Typ: SPACE, Pos: 1, Val: " "},
+		{Typ: IDENTIFIER, Pos: 2, Val: "\uFEFFb"},
+		{Typ: EOF, Pos: 6, Val: ""},
+	}},
+}
+
+func TestSkipBOM(t *testing.T) {
+	for _, test := range bomTests {
+		items := drain(New(test.name, test.input, lexIdents, test.opts...))
+		if !equal(items, test.items, true) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+func TestSkipBOMLossless(t *testing.T) {
+	const input = "\uFEFFa b"
+	items := drain(New("bom", input, lexIdents, SkipBOM(), Lossless(), CollectTrivia(SPACE)))
+	if err := VerifyRoundTrip(input, items); err != nil {
+		t.Error(err)
+	}
+	for _, item := range items {
+		if item.Typ == ERROR {
+			t.Errorf("unexpected error %v", item)
+		}
+	}
+}
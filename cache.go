@@ -0,0 +1,112 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// A Cache memoizes the items of inputs, so that tools that scan the same
+// unchanged files again and again, such as build tools and linters run by
+// editors, scan each of them once. Entries are keyed by a hash of the input
+// and the identity of the lexer, and the least recently used entries are
+// evicted when a limit is exceeded. A Cache is safe for concurrent use.
+type Cache struct {
+	maxEntries int
+	maxItems   int
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     list.List // of *cacheEntry, most recently used first
+	items   int       // number of items in all entries
+}
+
+type cacheKey struct {
+	lexer string
+	hash  [sha256.Size]byte
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	items []Item
+}
+
+// NewCache returns a cache holding up to maxEntries token streams with up
+// to maxItems items in total. A limit of 0 means no limit.
+func NewCache(maxEntries, maxItems int) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		maxItems:   maxItems,
+		entries:    make(map[cacheKey]*list.Element),
+	}
+}
+
+// Items returns the items cached for input and lexer, an arbitrary string
+// identifying the lexer and its configuration. If there are none, it
+// calls scan to produce them and caches the result. The returned slice is
+// shared and must not be modified.
+func (c *Cache) Items(lexer, input string, scan func() []Item) []Item {
+	key := cacheKey{lexer, sha256.Sum256([]byte(input))}
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cacheEntry).items
+	}
+	c.mu.Unlock()
+
+	items := scan()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.lru.PushFront(&cacheEntry{key, items})
+		c.items += len(items)
+		c.evict()
+	}
+	return items
+}
+
+// Scan returns the items of input scanned with New(name, input, start,
+// opts...), up to and including EOF, from the cache if possible. As for
+// Items, lexer identifies the lexer and its configuration: all calls with
+// the same lexer must pass equivalent start states and options. State
+// functions cannot serve as keys themselves, since closures and method
+// values created from the same code, such as the Lex methods of two
+// Rules, are indistinguishable.
+func (c *Cache) Scan(lexer, name, input string, start StateFn, opts ...Option) []Item {
+	return c.Items(lexer, input, func() []Item {
+		var items []Item
+		s := New(name, input, start, opts...)
+		for {
+			item := s.NextItem()
+			items = append(items, item)
+			if item.Typ == EOF {
+				return items
+			}
+		}
+	})
+}
+
+// Len returns the number of cached token streams.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// evict removes the least recently used entries until the cache is within
+// its limits. An entry exceeding maxItems on its own is not kept.
+func (c *Cache) evict() {
+	for c.lru.Len() > 0 && (c.maxEntries > 0 && c.lru.Len() > c.maxEntries || c.maxItems > 0 && c.items > c.maxItems) {
+		e := c.lru.Back()
+		entry := e.Value.(*cacheEntry)
+		c.lru.Remove(e)
+		delete(c.entries, entry.key)
+		c.items -= len(entry.items)
+	}
+}
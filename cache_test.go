@@ -0,0 +1,67 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache(2, 0)
+	scans := 0
+	scan := func(input string) []Item {
+		return c.Items("words", input, func() []Item {
+			scans++
+			return drain(New("cache", input, lexStart))
+		})
+	}
+	a := scan("a b")
+	if got := scan("a b"); scans != 1 || &got[0] != &a[0] {
+		t.Errorf("second scan of the same input: %d scans", scans)
+	}
+	c.Items("other", "a b", func() []Item { scans++; return nil })
+	if scans != 2 {
+		t.Errorf("different lexer shared the cache entry")
+	}
+	scan("c") // evicts "words" "a b", the least recently used entry
+	scan("a b")
+	if scans != 4 || c.Len() != 2 {
+		t.Errorf("eviction by entries: %d scans, %d entries", scans, c.Len())
+	}
+}
+
+func TestCacheMaxItems(t *testing.T) {
+	c := NewCache(0, 5)
+	c.Scan("start", "cache", "a b c", lexStart) // 4 items
+	c.Scan("start", "cache", "d", lexStart)     // 2 items, evicts the first
+	if c.Len() != 1 {
+		t.Errorf("got %d entries, expected 1", c.Len())
+	}
+	c.Scan("start", "cache", "1 2 3 4 5 6", lexStart) // 7 items, not kept
+	if c.Len() != 0 {
+		t.Errorf("got %d entries, expected 0", c.Len())
+	}
+	items := c.Scan("start", "cache", "x 1", lexStart)
+	if want := drain(New("full", "x 1", lexStart)); !equal(items, want, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, want)
+	}
+}
+
+func TestCacheScanKey(t *testing.T) {
+	c := NewCache(0, 0)
+	words := func(t ItemType) StateFn {
+		return func(s *Scanner) StateFn {
+			s.AcceptRun("ab")
+			s.Emit(t)
+			s.Emit(EOF)
+			return nil
+		}
+	}
+	c.Scan("a", "cache", "ab", words(IDENTIFIER))
+	items := c.Scan("b", "cache", "ab", words(INTEGER))
+	if items[0].Typ != INTEGER || c.Len() != 2 {
+		t.Errorf("got %v and %d entries for a second lexer", items, c.Len())
+	}
+}
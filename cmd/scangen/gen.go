@@ -0,0 +1,128 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+var funcs = template.FuncMap{
+	"stateName": stateName,
+	"matcher": func(r rule) string {
+		if r.Regexp {
+			return "scan.Regexp(" + quote(r.Pattern) + ")"
+		}
+		return "scan.Lit(" + strconv.Quote(r.Pattern) + ")"
+	},
+	"comment": func(r rule) string {
+		if r.Token == "" {
+			return "skip"
+		}
+		return r.Token
+	},
+}
+
+// stateName returns the name of the state function for a mode.
+func stateName(mode string) string {
+	r, n := utf8.DecodeRuneInString(mode)
+	return "lex" + string(unicode.ToUpper(r)) + mode[n:]
+}
+
+// quote returns a Go string literal for s, preferring a raw string.
+func quote(s string) string {
+	if strings.ContainsAny(s, "`\r") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+var lexerTemplate = template.Must(template.New("lexer").Funcs(funcs).Parse(`// Code generated by scangen from {{.File}}. It is meant to be edited;
+// running scangen again overwrites the changes.
+
+package {{.Spec.Package}}
+
+import "github.com/schulze/scan"
+
+// Item types of tokens.
+const (
+{{- range $i, $t := .Spec.Tokens}}
+	{{$t}}{{if eq $i 0}} scan.ItemType = iota{{end}}
+{{- end}}
+)
+
+// Names holds the names of the item types, for use with
+// scan.RegisterTypeName.
+var Names = map[scan.ItemType]string{
+{{- range .Spec.Tokens}}
+	{{.}}: "{{.}}",
+{{- end}}
+}
+
+// New returns a scanner for input, starting in mode {{(index .Spec.Modes 0).Name}}.
+func New(name, input string, opts ...scan.Option) *scan.Scanner {
+	return scan.New(name, input, {{stateName (index .Spec.Modes 0).Name}}, opts...)
+}
+{{range $i, $m := .Spec.Modes}}
+// {{$m.Name}}Patterns are the patterns of the rules of mode {{$m.Name}}.
+var {{$m.Name}}Patterns = []scan.Matcher{
+{{- range $m.Rules}}
+	{{matcher .}}, // {{comment .}}
+{{- end}}
+}
+
+// {{stateName $m.Name}} scans a token in mode {{$m.Name}}.
+// The longest match wins, and among patterns matching the same text the first.
+func {{stateName $m.Name}}(s *scan.Scanner) scan.StateFn {
+	if s.Peek() == scan.EOF {
+{{- if eq $i 0}}
+		s.Emit(scan.EOF)
+		return nil
+{{- else}}
+		return s.Errorf("unexpected end of input in mode {{$m.Name}}")
+{{- end}}
+	}
+	switch scan.Longest(s, {{$m.Name}}Patterns...) {
+{{- range $j, $r := $m.Rules}}
+	case {{$j}}:
+{{- if $r.Token}}
+		s.Emit({{$r.Token}})
+{{- else}}
+		s.Ignore()
+{{- end}}
+{{- if $r.Next}}
+		return {{stateName $r.Next}}
+{{- end}}
+{{- end}}
+	default:
+		return s.Errorf("unexpected %q", s.Next())
+	}
+	return {{stateName $m.Name}}
+}
+{{end}}`))
+
+// generate returns the Go source of the lexer described by sp, which was
+// read from file.
+func generate(sp *spec, file string) ([]byte, error) {
+	var b bytes.Buffer
+	data := struct {
+		File string
+		Spec *spec
+	}{file, sp}
+	if err := lexerTemplate.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerate(t *testing.T) {
+	src, err := os.ReadFile("testdata/calc.spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := parseSpec("calc.spec", string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(sp, "calc.spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const golden = "testdata/calc.golden"
+	if *update {
+		if err := os.WriteFile(golden, got, 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("generated code differs from %s:\n%s", golden, got)
+	}
+}
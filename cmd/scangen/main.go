@@ -0,0 +1,93 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Scangen generates a lexer for package scan from a spec file listing
+// token names, their patterns and modes. The generated code consists of
+// ordinary state functions, one per mode, and is meant to be edited: a
+// table-driven start that grows hand-written states where the patterns do
+// not suffice.
+//
+// Usage:
+//
+//	scangen [-o file.go] file.spec
+//
+// A spec is a sequence of lines, each of which is empty, a comment
+// starting with '#' or one of
+//
+//	package NAME
+//	mode NAME
+//	TOKEN PATTERN [-> MODE]
+//	skip PATTERN [-> MODE]
+//
+// A PATTERN is a Go string literal for literal text or a regular
+// expression, in the syntax of package regexp, between slashes, with "\/"
+// for a slash. At each position the longest match wins, and among
+// patterns matching the same text the first. A rule with "-> MODE"
+// switches to the given mode after the match. Rules before the first mode
+// line belong to the mode "main", and the first mode is the start mode.
+// For example, a calculator with strings:
+//
+//	package calc
+//
+//	NUMBER /[0-9]+(\.[0-9]+)?/
+//	PLUS   "+"
+//	QUOTE  "\"" -> string
+//	skip   /[ \t\n]+/
+//
+//	mode string
+//	TEXT   /([^"\\]|\\.)+/
+//	QUOTE  "\"" -> main
+//
+// The generated file declares the item types, a Names map for
+// scan.RegisterTypeName, a constructor New and the state functions.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var output = flag.String("o", "", "write the generated code to `file` instead of standard output")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: scangen [-o file.go] file.spec\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+	}
+	file := flag.Arg(0)
+	src, err := os.ReadFile(file)
+	if err != nil {
+		fatal(err)
+	}
+	sp, err := parseSpec(file, string(src))
+	if err != nil {
+		fatal(err)
+	}
+	code, err := generate(sp, filepath.Base(file))
+	if err != nil {
+		fatal(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = os.WriteFile(*output, code, 0o666)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "scangen: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,162 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A spec describes a lexer to generate.
+type spec struct {
+	Package string   // package name
+	Tokens  []string // names of the item types, in order of appearance
+	Modes   []*mode  // the first mode is the start mode
+}
+
+// A mode is a set of rules that apply together, such as the rules for the
+// tokens inside a string.
+type mode struct {
+	Name  string
+	Rules []rule
+}
+
+// A rule maps a pattern to an item type.
+type rule struct {
+	Token   string // item type; empty for a skip rule
+	Pattern string // literal text or regular expression
+	Regexp  bool
+	Next    string // mode to switch to after the token; empty to stay
+	Line    int    // line of the rule in the spec
+}
+
+// parseSpec parses the spec in src, read from the file name. The syntax is
+// described in the package comment.
+func parseSpec(name, src string) (*spec, error) {
+	sp := &spec{Package: "lexer"}
+	modes := make(map[string]*mode)
+	tokens := make(map[string]bool)
+	var cur *mode
+	addMode := func(name string) {
+		cur = &mode{Name: name}
+		modes[name] = cur
+		sp.Modes = append(sp.Modes, cur)
+	}
+	errorf := func(line int, format string, args ...interface{}) error {
+		return fmt.Errorf("%s:%d: %s", name, line, fmt.Sprintf(format, args...))
+	}
+	for i, text := range strings.Split(src, "\n") {
+		line := i + 1
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		word, rest := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			word, rest = text[:i], strings.TrimSpace(text[i:])
+		}
+		switch word {
+		case "package", "mode":
+			if !token.IsIdentifier(rest) {
+				return nil, errorf(line, "invalid %s name %q", word, rest)
+			}
+			if word == "package" {
+				sp.Package = rest
+				continue
+			}
+			if modes[rest] != nil {
+				return nil, errorf(line, "mode %s redefined", rest)
+			}
+			addMode(rest)
+			continue
+		case "skip":
+			word = ""
+		default:
+			if !token.IsIdentifier(word) || word == "New" || word == "Names" {
+				return nil, errorf(line, "invalid token name %q", word)
+			}
+		}
+		r, err := parseRule(word, rest)
+		if err != nil {
+			return nil, errorf(line, "%v", err)
+		}
+		r.Line = line
+		if cur == nil {
+			addMode("main")
+		}
+		cur.Rules = append(cur.Rules, r)
+		if r.Token != "" && !tokens[r.Token] {
+			tokens[r.Token] = true
+			sp.Tokens = append(sp.Tokens, r.Token)
+		}
+	}
+	if len(sp.Modes) == 0 {
+		return nil, fmt.Errorf("%s: no rules", name)
+	}
+	for _, m := range sp.Modes {
+		if len(m.Rules) == 0 {
+			return nil, fmt.Errorf("%s: mode %s has no rules", name, m.Name)
+		}
+		for _, r := range m.Rules {
+			if r.Next != "" && modes[r.Next] == nil {
+				return nil, errorf(r.Line, "undefined mode %s", r.Next)
+			}
+		}
+	}
+	return sp, nil
+}
+
+// parseRule parses the pattern and action of a rule for the token tok.
+func parseRule(tok, text string) (rule, error) {
+	r := rule{Token: tok}
+	switch {
+	case strings.HasPrefix(text, `"`):
+		lit, err := strconv.QuotedPrefix(text)
+		if err != nil {
+			return r, fmt.Errorf("invalid string literal in %s", text)
+		}
+		r.Pattern, _ = strconv.Unquote(lit)
+		if r.Pattern == "" {
+			return r, fmt.Errorf("empty pattern")
+		}
+		text = text[len(lit):]
+	case strings.HasPrefix(text, "/"):
+		var b strings.Builder
+		i := 1
+		for ; i < len(text) && text[i] != '/'; i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				if text[i+1] != '/' {
+					b.WriteByte('\\')
+				}
+				i++
+			}
+			b.WriteByte(text[i])
+		}
+		if i == len(text) {
+			return r, fmt.Errorf("unterminated regular expression in %s", text)
+		}
+		r.Pattern, r.Regexp = b.String(), true
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return r, err
+		}
+		text = text[i+1:]
+	default:
+		return r, fmt.Errorf("missing pattern")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return r, nil
+	}
+	next, ok := strings.CutPrefix(text, "->")
+	next = strings.TrimSpace(next)
+	if !ok || !token.IsIdentifier(next) {
+		return r, fmt.Errorf("unexpected %q after pattern", text)
+	}
+	r.Next = next
+	return r, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	src := "# comment\npackage p\n\nA \"a\\\"\"\n\tskip /[ ]+/ -> m\nmode m\nB\t/a\\/b\\./\nA \"x\" -> main\n"
+	got, err := parseSpec("test.spec", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &spec{
+		Package: "p",
+		Tokens:  []string{"A", "B"},
+		Modes: []*mode{
+			{Name: "main", Rules: []rule{
+				{Token: "A", Pattern: `a"`, Line: 4},
+				{Pattern: "[ ]+", Regexp: true, Next: "m", Line: 5},
+			}},
+			{Name: "m", Rules: []rule{
+				{Token: "B", Pattern: `a/b\.`, Regexp: true, Line: 7},
+				{Token: "A", Pattern: "x", Next: "main", Line: 8},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", got, want)
+	}
+}
+
+type specErrorTest struct {
+	src string
+	err string
+}
+
+var specErrorTests = []specErrorTest{
+	{"", "test.spec: no rules"},
+	{"mode m\nmode n\nA \"a\"", "test.spec: mode m has no rules"},
+	{"package 1p", `test.spec:1: invalid package name "1p"`},
+	{"A \"a\"\nmode main", "test.spec:2: mode main redefined"},
+	{"New \"a\"", `test.spec:1: invalid token name "New"`},
+	{"A a", "test.spec:1: missing pattern"},
+	{"A \"\"", "test.spec:1: empty pattern"},
+	{"A \"a", `test.spec:1: invalid string literal in "a`},
+	{"A /a", "test.spec:1: unterminated regular expression in /a"},
+	{"A /(/", "test.spec:1: error parsing regexp: missing closing ): `(`"},
+	{"A \"a\" b", `test.spec:1: unexpected "b" after pattern`},
+	{"A \"a\" -> x", "test.spec:1: undefined mode x"},
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	for _, test := range specErrorTests {
+		_, err := parseSpec("test.spec", test.src)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: got error %v, expected %q", test.src, err, test.err)
+		}
+	}
+}
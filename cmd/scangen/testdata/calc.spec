@@ -0,0 +1,11 @@
+# A calculator with strings.
+package calc
+
+NUMBER /[0-9]+(\.[0-9]+)?/
+PLUS   "+"
+QUOTE  "\"" -> string
+skip   /[ \t\n]+/
+
+mode string
+TEXT   /([^"\\]|\\.)+/
+QUOTE  "\"" -> main
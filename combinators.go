@@ -0,0 +1,205 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Matcher consumes a piece of input at the current position of s and
+// reports whether it matched. A matcher that does not match leaves the
+// position unchanged. Matchers are combined with Seq, OneOf, Repeat and
+// Optional and turned into state functions with MatchState and Tokenize,
+// so that simple token grammars can be written declaratively:
+//
+//	number := scan.Token(NUMBER, scan.Seq(
+//		scan.Repeat(scan.AnyOf(digits)),
+//		scan.Optional(scan.Seq(scan.Lit("."), scan.Repeat(scan.AnyOf(digits)))),
+//	))
+//	start := scan.Tokenize(scan.OneOf(number, scan.Skip(scan.Repeat(scan.AnyOf(" \n")))))
+//
+// A Matcher is an ordinary function, so hand-written matchers can be mixed
+// with the ones of this package, and MatchState passes control to a
+// hand-written state function after a match.
+type Matcher func(s *Scanner) bool
+
+// Lit returns a matcher for the literal string str.
+func Lit(str string) Matcher {
+	return func(s *Scanner) bool {
+		if !strings.HasPrefix(s.input[s.pos:], str) {
+			return false
+		}
+		s.pos += Pos(len(str))
+		s.width = 0
+		return true
+	}
+}
+
+// AnyOf returns a matcher for one rune from valid.
+func AnyOf(valid string) Matcher {
+	return func(s *Scanner) bool {
+		return s.Accept(valid)
+	}
+}
+
+// InSet returns a matcher for one rune from set.
+func InSet(set *RuneSet) Matcher {
+	return func(s *Scanner) bool {
+		return s.AcceptSet(set)
+	}
+}
+
+// Regexp returns a matcher for the longest match of the regular expression
+// expr, in the syntax of package regexp, at the current position. It
+// panics if expr does not compile.
+func Regexp(expr string) Matcher {
+	re := regexp.MustCompile(`\A(?:` + expr + `)`)
+	re.Longest()
+	return func(s *Scanner) bool {
+		loc := re.FindStringIndex(s.input[s.pos:])
+		if loc == nil {
+			return false
+		}
+		s.pos += Pos(loc[1])
+		s.width = 0
+		return true
+	}
+}
+
+// Seq returns a matcher for the sequence of ms. It matches if each of ms
+// matches in turn.
+//
+// Once a matcher in the sequence has emitted or ignored text, as Token and
+// Skip do, the sequence is committed: if a later matcher does not match,
+// the input is not given back and the sequence reports false at the point
+// of failure, which usually ends the scan with an error.
+func Seq(ms ...Matcher) Matcher {
+	return func(s *Scanner) bool {
+		c := s.Mark()
+		for _, m := range ms {
+			if !m(s) {
+				if s.start == c.start {
+					s.Rewind(c)
+				}
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// OneOf returns a matcher for the first of ms that matches. Unlike the
+// alternatives of a regular expression, the alternatives are tried in
+// order and the first match is taken, even if a later one would be longer.
+// No further alternative is tried after one has failed committed, as
+// described for Seq.
+func OneOf(ms ...Matcher) Matcher {
+	return func(s *Scanner) bool {
+		start := s.start
+		for _, m := range ms {
+			if m(s) {
+				return true
+			}
+			if s.start != start {
+				return false
+			}
+		}
+		return false
+	}
+}
+
+// Repeat returns a matcher for one or more repetitions of m. It stops at
+// the first repetition of m that does not match or matches the empty
+// string.
+func Repeat(m Matcher) Matcher {
+	return func(s *Scanner) bool {
+		n := 0
+		for p := s.pos; m(s); p = s.pos {
+			n++
+			if s.pos == p {
+				break
+			}
+		}
+		return n > 0
+	}
+}
+
+// Optional returns a matcher for zero or one occurrence of m. It always
+// matches; combine it with Repeat for zero or more occurrences.
+func Optional(m Matcher) Matcher {
+	return func(s *Scanner) bool {
+		m(s)
+		return true
+	}
+}
+
+// Token returns a matcher that matches like m and then emits the pending
+// text as an item of type t.
+func Token(t ItemType, m Matcher) Matcher {
+	return func(s *Scanner) bool {
+		if !m(s) {
+			return false
+		}
+		s.Emit(t)
+		return true
+	}
+}
+
+// Skip returns a matcher that matches like m and then ignores the pending
+// text, for white space and comments.
+func Skip(m Matcher) Matcher {
+	return func(s *Scanner) bool {
+		if !m(s) {
+			return false
+		}
+		s.Ignore()
+		return true
+	}
+}
+
+// MatchState returns a state function that applies m and continues with
+// next. If m does not match, the state function emits an error item and
+// terminates the scan.
+func MatchState(m Matcher, next StateFn) StateFn {
+	return func(s *Scanner) StateFn {
+		if !m(s) {
+			s.noMatch()
+			return nil
+		}
+		return next
+	}
+}
+
+// Tokenize returns a state function that applies m repeatedly until the
+// end of the input, where it emits EOF. If m does not match, or matches
+// without consuming input, the state function emits an error item and
+// terminates the scan.
+func Tokenize(m Matcher) StateFn {
+	var state StateFn
+	state = func(s *Scanner) StateFn {
+		if s.Peek() == EOF {
+			s.Emit(EOF)
+			return nil
+		}
+		if p := s.pos; !m(s) || s.pos == p {
+			s.noMatch()
+			return nil
+		}
+		return state
+	}
+	return state
+}
+
+// noMatch emits an error item for input that no matcher matched.
+func (s *Scanner) noMatch() {
+	found := "EOF"
+	if r := s.Peek(); r != EOF {
+		found = strconv.QuoteRune(r)
+	}
+	s.errorAt(s.pos, fmt.Errorf("no match for %s at %s", found, s.lineCol(s.pos)))
+}
@@ -0,0 +1,112 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"testing"
+)
+
+var (
+	digits      = Repeat(AnyOf("0123456789"))
+	combNumber  = Token(INTEGER, Seq(digits, Optional(Seq(Lit("."), digits))))
+	combIdent   = Token(IDENTIFIER, Seq(InSet(NewRuneSet("_").AddRange('a', 'z')), Optional(Repeat(InSet(identRunes)))))
+	combSpace   = Skip(Repeat(AnyOf(" \n")))
+	combPlus    = Token(PLUS, Lit("+"))
+	combGrammar = OneOf(combNumber, combIdent, combSpace, combPlus)
+)
+
+type matcherTest struct {
+	name  string
+	m     Matcher
+	input string
+	match bool
+	text  string // consumed input
+}
+
+var matcherTests = []matcherTest{
+	{"lit", Lit("ab"), "abc", true, "ab"},
+	{"lit fails", Lit("ab"), "a", false, ""},
+	{"regexp", Regexp(`[a-c]+|ab?`), "abcd", true, "abc"},
+	{"regexp anchored", Regexp(`b`), "ab", false, ""},
+	{"seq", Seq(Lit("a"), AnyOf("bc"), Lit("d")), "acd!", true, "acd"},
+	{"seq rewinds", Seq(Lit("a"), AnyOf("bc"), Lit("d")), "acx", false, ""},
+	{"one of", OneOf(Lit("a"), Lit("ab")), "ab", true, "a"},
+	{"one of second", OneOf(Lit("x"), Seq(Lit("a"), Lit("b"))), "ab", true, "ab"},
+	{"repeat", Repeat(AnyOf("ab")), "abbac", true, "abba"},
+	{"repeat none", Repeat(AnyOf("ab")), "c", false, ""},
+	{"repeat empty", Repeat(Optional(Lit("x"))), "ab", true, ""},
+	{"optional", Optional(Lit("x")), "ab", true, ""},
+}
+
+func TestMatchers(t *testing.T) {
+	for _, test := range matcherTests {
+		s := &Scanner{input: test.input}
+		if got := test.m(s); got != test.match || s.input[:s.pos] != test.text {
+			t.Errorf("%s: got %v, %q, expected %v, %q", test.name, got, s.input[:s.pos], test.match, test.text)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	s := New("tokenize", "x1 + 2.5\n+y", Tokenize(combGrammar))
+	got := drain(s)
+	want := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "x1"},
+		{Typ: PLUS, Pos: 3, Val: "+"},
+		{Typ: INTEGER, Pos: 5, Val: "2.5"},
+		{Typ: PLUS, Pos: 9, Val: "+"},
+		{Typ: IDENTIFIER, Pos: 10, Val: "y"},
+		{Typ: EOF, Pos: 11},
+	}
+	if !equal(got, want, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", got, want)
+	}
+}
+
+func TestTokenizeError(t *testing.T) {
+	s := New("error", "1 - 2", Tokenize(combGrammar))
+	got := drain(s)
+	want := []Item{
+		{Typ: INTEGER, Pos: 0, Val: "1"},
+		{Typ: ERROR, Pos: 2, Val: "no match for '-' at 1:3"},
+		{Typ: EOF, Pos: 5},
+	}
+	if !equal(got, want, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", got, want)
+	}
+}
+
+// TestSeqCommitted checks that a sequence does not give back input after
+// an item has been emitted.
+func TestSeqCommitted(t *testing.T) {
+	assign := Seq(combIdent, Skip(Optional(combSpace)), Token(MINUS, Lit("=")), combNumber)
+	s := New("committed", "a = b", MatchState(OneOf(assign, combIdent), lexStart))
+	got := drain(s)
+	want := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: MINUS, Pos: 2, Val: "="},
+		{Typ: ERROR, Pos: 3, Val: "no match for ' ' at 1:4"},
+		{Typ: EOF, Pos: 5},
+	}
+	if !equal(got, want, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", got, want)
+	}
+}
+
+// TestMatchState checks that a state built from matchers hands over to a
+// hand-written state.
+func TestMatchState(t *testing.T) {
+	s := New("match state", "x1 (* c *) + 2", MatchState(combIdent, lexStart))
+	got := drain(s)
+	want := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "x1"},
+		{Typ: PLUS, Pos: 11, Val: "+"},
+		{Typ: INTEGER, Pos: 13, Val: "2"},
+		{Typ: EOF, Pos: 14},
+	}
+	if !equal(got, want, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "errors"
+
+// A SourceStore provides the text of the input that items were scanned
+// from. A Scanner is a SourceStore, as is a StringSource, which may also
+// hold the contents of a memory-mapped file.
+type SourceStore interface {
+	// Slice returns the input between start and end.
+	Slice(start, end Pos) string
+}
+
+// StringSource is a SourceStore holding the input as a string.
+type StringSource string
+
+// Slice returns the input between start and end, which are clamped to it.
+func (s StringSource) Slice(start, end Pos) string {
+	start = max(start, 0)
+	end = min(end, Pos(len(s)))
+	if end <= start {
+		return ""
+	}
+	return string(s[start:end])
+}
+
+// A CompactItem is an item reduced to its type and the span of its text.
+type CompactItem struct {
+	Typ ItemType
+	Pos Pos
+	Len int // length of the text in bytes
+}
+
+// CompactItems holds a sequence of items as CompactItems, which do not
+// refer to the input, so that long token streams take little memory and
+// the input can be released or kept in a file while they are alive. The
+// values of the items are materialized from a SourceStore on demand. Like
+// EncodeItems, CompactItems only stores the values that differ from the
+// text of the input at the span of their item, such as the messages of
+// error items. Trivia and the decoded values of literals are dropped.
+type CompactItems struct {
+	Items  []CompactItem
+	values map[int]string // values differing from the input, by index
+}
+
+// Compact returns items, which were scanned from src, as CompactItems.
+func Compact(items []Item, src SourceStore) *CompactItems {
+	c := &CompactItems{Items: make([]CompactItem, 0, len(items))}
+	for _, item := range items {
+		c.Append(item, src)
+	}
+	return c
+}
+
+// Append adds item, which was scanned from src, to c.
+func (c *CompactItems) Append(item Item, src SourceStore) {
+	end := item.End()
+	if item.Val != src.Slice(item.Pos, end) {
+		if c.values == nil {
+			c.values = make(map[int]string)
+		}
+		c.values[len(c.Items)] = item.Val
+	}
+	c.Items = append(c.Items, CompactItem{Typ: item.Typ, Pos: item.Pos, Len: int(end - item.Pos)})
+}
+
+// Len returns the number of items in c.
+func (c *CompactItems) Len() int {
+	return len(c.Items)
+}
+
+// Item returns the k-th item of c with its value taken from src, which
+// must provide the input the items were scanned from. The error of an
+// ERROR or WARNING item is recreated from its value.
+func (c *CompactItems) Item(k int, src SourceStore) Item {
+	ci := c.Items[k]
+	end := ci.Pos + Pos(ci.Len)
+	val, ok := c.values[k]
+	if !ok {
+		val = src.Slice(ci.Pos, end)
+	}
+	item := Item{Typ: ci.Typ, Pos: ci.Pos, Val: val}.withEnd(end)
+	if item.Typ == ERROR || item.Typ == WARNING {
+		item.err = errors.New(val)
+	}
+	return item
+}
@@ -0,0 +1,44 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	const input = "abc (12 + x) ?"
+	upper := Map(func(item Item) Item {
+		if item.Val == "x" {
+			item.Val = "X"
+		}
+		return item
+	})
+	s := New("compact", input, lexStart, upper)
+	items := drain(s)
+	c := Compact(items, s)
+	if c.Len() != len(items) {
+		t.Fatalf("got %d items, expected %d", c.Len(), len(items))
+	}
+	if len(c.values) != 2 {
+		t.Errorf("got %d stored values, expected 2 for the mapped and the error item: %v", len(c.values), c.values)
+	}
+	src := StringSource(strings.Clone(input))
+	var got []Item
+	for k := 0; k < c.Len(); k++ {
+		item := c.Item(k, src)
+		if item.End() != items[k].End() {
+			t.Errorf("%+v: got end %d, expected %d", item, item.End(), items[k].End())
+		}
+		got = append(got, item)
+	}
+	if !equal(got, items, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", got, items)
+	}
+	if err := got[len(got)-2].Err(); err == nil || err.Error() != "lex error" {
+		t.Errorf("got error %v, expected lex error", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "strings"
+
+// confusables maps runes to their prototypes, following the Unicode
+// confusables data of UTS #39. It holds the small subset most often used
+// for spoofing identifiers: Cyrillic, Greek and Cherokee letters that look
+// like Latin ones, and easily confused ASCII characters.
+var confusables = map[rune]string{
+	// ASCII.
+	'0': "O", '1': "l", 'I': "l", '|': "l", 'm': "rn",
+	// Cyrillic.
+	'а': "a", 'с': "c", 'ԁ': "d", 'е': "e", 'һ': "h", 'і': "i", 'ј': "j",
+	'о': "o", 'р': "p", 'ԛ': "q", 'ѕ': "s", 'у': "y", 'ԝ': "w", 'х': "x",
+	'А': "A", 'В': "B", 'С': "C", 'Е': "E", 'Н': "H", 'І': "l", 'Ј': "J",
+	'К': "K", 'М': "M", 'О': "O", 'Р': "P", 'Ԛ': "Q", 'Ѕ': "S", 'Т': "T",
+	'Ԝ': "W", 'Х': "X",
+	// Greek.
+	'α': "a", 'ι': "i", 'ν': "v", 'ο': "o", 'ρ': "p",
+	'Α': "A", 'Β': "B", 'Ε': "E", 'Ζ': "Z", 'Η': "H", 'Ι': "l", 'Κ': "K",
+	'Μ': "M", 'Ν': "N", 'Ο': "O", 'Ρ': "P", 'Τ': "T", 'Υ': "Y", 'Χ': "X",
+	// Cherokee.
+	'Ꭺ': "A", 'Ᏼ': "B", 'Ꮯ': "C", 'Ꭼ': "E", 'Ꮋ': "H", 'Ꮶ': "K", 'Ꮇ': "M",
+	'Ꮲ': "P", 'Ꮪ': "S", 'Ꭲ': "T", 'Ꮃ': "W",
+}
+
+// Skeleton returns the confusable skeleton of s in the sense of UTS #39:
+// two strings that look alike, such as "pay" in Latin and "рау" with
+// Cyrillic letters, have the same skeleton. Skeleton uses a small built-in
+// subset of the Unicode confusables data.
+func Skeleton(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if p, ok := confusables[r]; ok {
+			b.WriteString(p)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// A ConfusableChecker reports identifiers that look like, but differ from,
+// identifiers seen before or keywords, as linters for spoofed source code
+// do. The zero value checks identifiers against each other only.
+type ConfusableChecker struct {
+	seen map[string]string // first identifier seen for each skeleton
+	fold bool              // identifiers are compared ignoring case
+}
+
+// NewConfusableChecker returns a checker for identifiers that are
+// confusable with each other or with the keywords of k, which may be nil.
+// If k was created with NewKeywordSetFold, identifiers are compared
+// ignoring case, as the keywords are matched, and the keywords are
+// reported in their case-folded form.
+func NewConfusableChecker(k *KeywordSet) *ConfusableChecker {
+	c := &ConfusableChecker{seen: make(map[string]string)}
+	if k != nil {
+		c.fold = k.fold
+		for word := range k.words {
+			c.seen[c.skeleton(word)] = word
+		}
+	}
+	return c
+}
+
+// skeleton returns the skeleton of ident under which c records it.
+func (c *ConfusableChecker) skeleton(ident string) string {
+	if c.fold {
+		return Skeleton(foldString(ident))
+	}
+	return Skeleton(ident)
+}
+
+// Check records ident and returns the keyword or previously checked
+// identifier that ident is confusable with, if any.
+func (c *ConfusableChecker) Check(ident string) (string, bool) {
+	if c.seen == nil {
+		c.seen = make(map[string]string)
+	}
+	skel := c.skeleton(ident)
+	prev, ok := c.seen[skel]
+	if !ok {
+		c.seen[skel] = ident
+		return "", false
+	}
+	if prev == ident || c.fold && foldString(prev) == foldString(ident) {
+		return "", false
+	}
+	return prev, true
+}
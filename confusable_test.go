@@ -0,0 +1,70 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestSkeleton(t *testing.T) {
+	if Skeleton("pay") != Skeleton("рау") {
+		t.Errorf("Latin and Cyrillic pay have different skeletons")
+	}
+	if Skeleton("rn") != Skeleton("m") {
+		t.Errorf("rn and m have different skeletons")
+	}
+	if Skeleton("pay") == Skeleton("bay") {
+		t.Errorf("pay and bay have the same skeleton")
+	}
+}
+
+type confusableTest struct {
+	ident string
+	prev  string
+	ok    bool
+}
+
+var confusableTests = []confusableTest{
+	{"count", "", false},
+	{"count", "", false},
+	{"cоunt", "count", true}, // Cyrillic o
+	{"c0unt", "", false},
+	{"cOunt", "c0unt", true},
+	{"іnt", "int", true}, // Cyrillic i
+	{"int", "", false},
+}
+
+func TestConfusableChecker(t *testing.T) {
+	c := NewConfusableChecker(keywords)
+	for _, test := range confusableTests {
+		prev, ok := c.Check(test.ident)
+		if prev != test.prev || ok != test.ok {
+			t.Errorf("Check(%q): got %q, %v expected %q, %v", test.ident, prev, ok, test.prev, test.ok)
+		}
+	}
+	var zero ConfusableChecker
+	zero.Check("pay")
+	if prev, ok := zero.Check("рау"); !ok || prev != "pay" {
+		t.Errorf("zero value: got %q, %v", prev, ok)
+	}
+}
+
+func TestConfusableCheckerFold(t *testing.T) {
+	c := NewConfusableChecker(foldKeywords)
+	tests := []confusableTest{
+		{"INT", "", false},
+		{"Int", "", false},
+		{"IᏁT", "", false},
+		{"ІNT", "INT", true}, // Cyrillic I
+		{"inТ", "INT", true}, // Cyrillic T
+		{"SELEᏟT", "", false},
+		{"select", "SELEᏟT", true},
+		{"sеlect", "SELEᏟT", true}, // Cyrillic e
+	}
+	for _, test := range tests {
+		prev, ok := c.Check(test.ident)
+		if prev != test.prev || ok != test.ok {
+			t.Errorf("Check(%q): got %q, %v expected %q, %v", test.ident, prev, ok, test.prev, test.ok)
+		}
+	}
+}
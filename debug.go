@@ -0,0 +1,79 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// debugState mirrors the state of the scanner for DebugState, which may
+// run on any goroutine. The state of the lexer is updated after each state
+// function, the number of queued items by the client.
+type debugState struct {
+	pos      atomic.Int64
+	start    atomic.Int64
+	state    atomic.Uintptr // key of the next state function; 0 for nil
+	finished atomic.Bool    // the state machine has stopped
+	queued   atomic.Int64   // items received or peeked but not yet taken by the client
+}
+
+// update records the current state of s.
+func (d *debugState) update(s *Scanner) {
+	d.pos.Store(int64(s.pos))
+	d.start.Store(int64(s.start))
+	if s.state == nil {
+		d.state.Store(0)
+	} else {
+		d.state.Store(stateKey(s.state))
+	}
+}
+
+// setQueued records the number of items queued on the client's side of s.
+func (d *debugState) setQueued(s *Scanner) {
+	d.queued.Store(int64(len(s.received) + len(s.lookahead)))
+}
+
+// maxDebugPending is the length up to which DebugState shows the pending
+// text.
+const maxDebugPending = 40
+
+// DebugState returns a one-line description of the state of the scan for
+// bug reports about hangs or wrong items: the position of the lexer, the
+// next state function, the pending text and the number of items the lexer
+// has emitted but the client has not yet taken. The lexer is described as
+// of the last return of a state function. DebugState may be called from
+// any goroutine while the scan is running, for example after
+// NextItemTimeout has timed out.
+func (s *Scanner) DebugState() string {
+	var b strings.Builder
+	pos, start := Pos(s.debug.pos.Load()), Pos(s.debug.start.Load())
+	fmt.Fprintf(&b, "scanner %q at %s (offset %d)", s.name, s.lineCol(pos), pos)
+	switch key := s.debug.state.Load(); {
+	case s.debug.finished.Load():
+		b.WriteString(", finished")
+	case key == 0:
+		b.WriteString(", stopping")
+	default:
+		fmt.Fprintf(&b, ", in state %s", stateNameOf(key))
+	}
+	s.mu.RLock()
+	pending := s.input[start:pos]
+	s.mu.RUnlock()
+	if len(pending) > maxDebugPending {
+		fmt.Fprintf(&b, ", pending %q... (%d bytes)", pending[:maxDebugPending], len(pending))
+	} else {
+		fmt.Fprintf(&b, ", pending %q", pending)
+	}
+	fmt.Fprintf(&b, ", %d items and %d batches queued", s.debug.queued.Load(), len(s.items))
+	return b.String()
+}
+
+// String returns DebugState, so that printing a scanner with the %v verb
+// gives its state.
+func (s *Scanner) String() string {
+	return s.DebugState()
+}
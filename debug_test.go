@@ -0,0 +1,85 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var (
+	hangEntered = make(chan bool)
+	hangRelease = make(chan bool)
+)
+
+// lexBeforeHang consumes two runes without emitting them and continues
+// with lexHang, which waits until the test releases it.
+func lexBeforeHang(s *Scanner) StateFn {
+	s.Next()
+	s.Next()
+	return lexHang
+}
+
+func lexHang(s *Scanner) StateFn {
+	hangEntered <- true
+	<-hangRelease
+	s.Emit(IDENTIFIER)
+	s.Emit(EOF)
+	return nil
+}
+
+func TestDebugState(t *testing.T) {
+	s := New("debug", "ab\ncd", lexBeforeHang)
+	<-hangEntered
+	want := `scanner "debug" at 1:3 (offset 2), in state scan.lexHang, pending "ab", 0 items and 0 batches queued`
+	if got := s.DebugState(); got != want {
+		t.Errorf("got\n\t%s\nexpected\n\t%s", got, want)
+	}
+	if got := fmt.Sprint(s); got != want {
+		t.Errorf("String: got\n\t%s\nexpected\n\t%s", got, want)
+	}
+	hangRelease <- true
+	s.PeekItem()
+	drain(s)
+	if got := s.DebugState(); !strings.Contains(got, ", finished,") {
+		t.Errorf("got %s after the scan", got)
+	}
+}
+
+func TestDebugStatePending(t *testing.T) {
+	s := New("long", strings.Repeat("x", 100), func(s *Scanner) StateFn {
+		for s.Next() != EOF {
+		}
+		return nil
+	})
+	drain(s)
+	want := fmt.Sprintf("pending %q... (100 bytes)", strings.Repeat("x", maxDebugPending))
+	if got := s.DebugState(); !strings.Contains(got, want) {
+		t.Errorf("got %s, expected it to contain %s", got, want)
+	}
+}
+
+func TestDebugStateConcurrent(t *testing.T) {
+	s := New("concurrent", "a b c", lexStart)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.DebugState()
+		}
+		done <- true
+	}()
+	s.PeekItemN(1)
+	drain(s)
+	<-done
+	if got := s.DebugState(); !strings.Contains(got, ", 0 items and") {
+		t.Errorf("got %s after the scan", got)
+	}
+	s = New("queued", "a b c", lexStart)
+	s.PeekItemN(1)
+	if got := s.DebugState(); strings.Contains(got, ", 0 items and") {
+		t.Errorf("got %s with peeked items", got)
+	}
+}
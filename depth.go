@@ -0,0 +1,84 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "fmt"
+
+// A Bracket pairs the item types that open and close a bracketed
+// construct, such as parentheses. The opening type is the kind of the
+// bracket, by which Depth and the related methods refer to it.
+type Bracket struct {
+	Open, Close ItemType
+}
+
+// TrackBrackets makes the scanner track the depth of the given brackets
+// as items are emitted: an item of an opening type increases the depth of
+// its kind and an item of the closing type decreases it. A closing item
+// without a matching opening one is reported with an error item, and an
+// opening item that exceeds the limit set with MaxNesting is replaced by
+// one, as described for EnterNesting. Lexers can consult the depths to
+// make context-sensitive decisions, such as treating newlines as
+// significant only outside of brackets.
+func TrackBrackets(brackets ...Bracket) Option {
+	return func(s *Scanner) {
+		s.brackets = append(s.brackets, brackets...)
+	}
+}
+
+// trackBracket updates the depths for an emitted item.
+func (s *Scanner) trackBracket(item Item) {
+	for _, b := range s.brackets {
+		switch item.Typ {
+		case b.Open:
+			s.openDepthAt(b.Open, item.Pos)
+			return
+		case b.Close:
+			if !s.CloseDepth(b.Open) {
+				s.errorAt(item.Pos, fmt.Errorf("unmatched %s at %s", s.names().String(item), s.lineCol(item.Pos)))
+			}
+			return
+		}
+	}
+}
+
+// OpenDepth increments the depth of the given kind, as a state function
+// does when it opens a bracket that is not tracked with TrackBrackets.
+// Brackets count towards the nesting depth and its limit: OpenDepth
+// increments the depth with EnterNesting and returns its result.
+func (s *Scanner) OpenDepth(kind ItemType) bool {
+	return s.openDepthAt(kind, s.start)
+}
+
+// openDepthAt is OpenDepth for a bracket opened at p.
+func (s *Scanner) openDepthAt(kind ItemType, p Pos) bool {
+	if s.depths == nil {
+		s.depths = make(map[ItemType]int)
+	}
+	s.depths[kind]++
+	return s.enterNestingAt(p)
+}
+
+// CloseDepth decrements the depth of the given kind, and the nesting
+// depth with it, and reports whether it was positive. A depth of 0 is
+// left unchanged.
+func (s *Scanner) CloseDepth(kind ItemType) bool {
+	switch d := s.depths[kind]; d {
+	case 0:
+		return false
+	case 1:
+		delete(s.depths, kind)
+	default:
+		s.depths[kind] = d - 1
+	}
+	s.LeaveNesting()
+	return true
+}
+
+// Depth returns the depth of the given kind. The sum of the depths of all
+// kinds is part of Nesting, which also counts the constructs entered with
+// EnterNesting.
+func (s *Scanner) Depth(kind ItemType) int {
+	return s.depths[kind]
+}
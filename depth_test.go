@@ -0,0 +1,88 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestDepth(t *testing.T) {
+	var depths []byte
+	lexBrackets := func(s *Scanner) StateFn {
+		for {
+			switch s.Next() {
+			case EOF:
+				s.Emit(EOF)
+				return nil
+			case '(':
+				s.Emit(LPAREN)
+			case ')':
+				s.Emit(RPAREN)
+			case '[':
+				s.OpenDepth('[')
+				s.Ignore()
+			case ']':
+				if !s.CloseDepth('[') {
+					s.EmitError("unmatched ]")
+				}
+				s.Ignore()
+			default:
+				s.Emit(IDENTIFIER)
+			}
+			depths = strconv.AppendInt(depths, int64(s.Depth(LPAREN)), 10)
+			depths = strconv.AppendInt(depths, int64(s.Nesting()), 10)
+			depths = append(depths, ' ')
+		}
+	}
+	items := drain(New("depth", "(a[(b)])]) c", lexBrackets, TrackBrackets(Bracket{LPAREN, RPAREN})))
+	expected := []Item{
+		tLparen,
+		{Typ: IDENTIFIER, Val: "a"},
+		tLparen,
+		{Typ: IDENTIFIER, Val: "b"},
+		tRparen,
+		tRparen,
+		{Typ: ERROR, Val: "unmatched ]"},
+		{Typ: ERROR, Val: `unmatched ")" at 1:10`},
+		tRparen,
+		{Typ: IDENTIFIER, Val: " "},
+		{Typ: IDENTIFIER, Val: "c"},
+		tEOF,
+	}
+	if !equal(items, expected, false) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+	if got := string(depths); got != "11 11 12 23 23 12 11 00 00 00 00 00 " {
+		t.Errorf("got depths %s", got)
+	}
+}
+
+func TestDepthSubScan(t *testing.T) {
+	items := drain(New("interpolation", `"${1)}"`, lexInterpolated, TrackBrackets(Bracket{LPAREN, RPAREN})))
+	expected := []Item{
+		{Typ: IDENTIFIER, Pos: 1, Val: ""},
+		{Typ: INTEGER, Pos: 3, Val: "1"},
+		{Typ: ERROR, Pos: 4, Val: `unmatched ")" at 1:5`},
+		{Typ: RPAREN, Pos: 4, Val: ")"},
+		{Typ: IDENTIFIER, Pos: 6, Val: ""},
+		{Typ: EOF, Pos: 7},
+	}
+	if !equal(items, expected, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+}
+
+func TestDepthMaxNesting(t *testing.T) {
+	items := drain(New("deep", "((a))", lexStart, TrackBrackets(Bracket{LPAREN, RPAREN}), MaxNesting(1)))
+	expected := []Item{
+		tLparen,
+		{Typ: ERROR, Pos: 1, Val: "nesting too deep: more than 1 levels at 1:2"},
+		{Typ: EOF, Pos: 5},
+	}
+	if !equal(items, expected, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+}
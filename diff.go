@@ -0,0 +1,97 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffFlags control which fields of items Diff compares.
+type DiffFlags int
+
+const (
+	IgnoreType DiffFlags = 1 << iota // do not compare types
+	IgnorePos                        // do not compare positions
+	IgnoreVal                        // do not compare values
+)
+
+// maxDiffCells bounds the size of the table used to align the differing
+// middle parts of two streams.
+const maxDiffCells = 1 << 22
+
+// Diff compares two item streams and returns a report of their
+// differences, or the empty string if they are equal. The report lists
+// the items only in a, prefixed with "-", and the items only in b,
+// prefixed with "+", each with its index in its stream. It is meant for
+// regression tests of lexers and for comparing versions of a lexer.
+func Diff(a, b []Item, flags DiffFlags) string {
+	return TypeNames(nil).Diff(a, b, flags)
+}
+
+// Diff is like the function Diff but reports the type names in n.
+func (n TypeNames) Diff(a, b []Item, flags DiffFlags) string {
+	same := func(x, y Item) bool {
+		return (flags&IgnoreType != 0 || x.Typ == y.Typ) &&
+			(flags&IgnorePos != 0 || x.Pos == y.Pos) &&
+			(flags&IgnoreVal != 0 || x.Val == y.Val)
+	}
+	// Strip the common prefix and suffix.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && same(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && same(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(x) == 0 && len(y) == 0 {
+		return ""
+	}
+	var report strings.Builder
+	line := func(op byte, i int, item Item) {
+		fmt.Fprintf(&report, "%c[%d] %s %d %q\n", op, prefix+i, n.Name(item.Typ), item.Pos, item.Val)
+	}
+	if len(x)*len(y) > maxDiffCells {
+		for i, item := range x {
+			line('-', i, item)
+		}
+		for j, item := range y {
+			line('+', j, item)
+		}
+		return report.String()
+	}
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if same(x[i], y[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && same(x[i], y[j]):
+			i++
+			j++
+		case j == len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1]:
+			line('-', i, x[i])
+			i++
+		default:
+			line('+', j, y[j])
+			j++
+		}
+	}
+	return report.String()
+}
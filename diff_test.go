@@ -0,0 +1,31 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	a := collect(&lexTest{"a", "(a + 1) - b", nil}, "", "")
+	b := collect(&lexTest{"b", "(a + 2) - b c", nil}, "", "")
+	expected := `-[3] INTEGER 5 "1"
++[3] INTEGER 5 "2"
+-[7] EOF 11 ""
++[7] IDENTIFIER 12 "c"
++[8] EOF 13 ""
+`
+	if got := Diff(a, b, 0); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+	if got := Diff(a, a, 0); got != "" {
+		t.Errorf("got differences for equal streams:\n%s", got)
+	}
+	c := collect(&lexTest{"c", "( a+1 )-b", nil}, "", "")
+	if got := Diff(a, c, 0); got == "" {
+		t.Errorf("got no differences for different positions")
+	}
+	if got := Diff(a, c, IgnorePos); got != "" {
+		t.Errorf("got differences ignoring positions:\n%s", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// A stage processes an item on its way to the client. It returns the item
+// to pass on and whether to pass it on at all.
+type stage func(Item) (Item, bool)
+
+// addStage appends f to the stages run by emit.
+func (s *Scanner) addStage(f stage) {
+	prev := s.stage
+	if prev == nil {
+		s.stage = f
+		return
+	}
+	s.stage = func(item Item) (Item, bool) {
+		item, ok := prev(item)
+		if !ok {
+			return item, false
+		}
+		return f(item)
+	}
+}
+
+// Filter makes the scanner drop the items for which keep returns false
+// before they reach the client, for example to hide white space and
+// comment tokens from a parser while the same lexer serves a formatter
+// without the option. Dropped error items do not count towards the
+// MaxErrors limit. With the CollectTrivia option, dropped items other than
+// errors and warnings are not discarded but attached as trivia to the next
+// item.
+//
+// Filter and Map may be given several times; the stages run in the order
+// of the options.
+func Filter(keep func(Item) bool) Option {
+	return func(s *Scanner) {
+		s.addStage(func(item Item) (Item, bool) {
+			return item, keep(item)
+		})
+	}
+}
+
+// Map makes the scanner replace each item by the result of f before it
+// reaches the client, so that items can be rewritten in flight, for
+// example to normalize the case of identifiers, to give keywords their own
+// type or to redact values, without changing the state functions.
+// Unless f moves the item, End still reports the end of the original text.
+func Map(f func(Item) Item) Option {
+	return func(s *Scanner) {
+		s.addStage(func(item Item) (Item, bool) {
+			mapped := f(item)
+			if mapped.Pos == item.Pos {
+				mapped = mapped.withEnd(item.End())
+			}
+			return mapped, true
+		})
+	}
+}
+
+// process runs the stages on item. If they drop it, process records it as
+// trivia if trivia are collected.
+func (s *Scanner) process(item Item) (Item, bool) {
+	if s.stage == nil {
+		return item, true
+	}
+	item, ok := s.stage(item)
+	if !ok && s.trivia.enabled && item.Typ != ERROR && item.Typ != WARNING && item.Typ != EOF {
+		s.trivia.pending = append(s.trivia.pending, item)
+	}
+	return item, ok
+}
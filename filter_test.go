@@ -0,0 +1,109 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+// lexSpaced scans identifiers, emitting the spaces between them.
+func lexSpaced(s *Scanner) StateFn {
+	w := AllSpace
+	w.Emit, w.Type = true, SPACE
+	switch {
+	case s.SkipSpace(w):
+	case s.Peek() == EOF:
+		s.Emit(EOF)
+		return nil
+	case s.Accept("!"):
+		s.Errorf("bang")
+		s.Ignore()
+	default:
+		s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		s.Emit(IDENTIFIER)
+	}
+	return lexSpaced
+}
+
+func notType(t ItemType) func(Item) bool {
+	return func(item Item) bool { return item.Typ != t }
+}
+
+type filterTest struct {
+	name  string
+	opts  []Option
+	items []Item
+}
+
+var filterTests = []filterTest{
+	{"none", nil, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: SPACE, Pos: 1, Val: " "},
+		{Typ: ERROR, Pos: 2, Val: "bang"},
+		{Typ: IDENTIFIER, Pos: 3, Val: "b"},
+		{Typ: EOF, Pos: 4, Val: ""},
+	}},
+	{"space", []Option{Filter(notType(SPACE))}, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: ERROR, Pos: 2, Val: "bang"},
+		{Typ: IDENTIFIER, Pos: 3, Val: "b"},
+		{Typ: EOF, Pos: 4, Val: ""},
+	}},
+	{"space and error", []Option{Filter(notType(SPACE)), Filter(notType(ERROR))}, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: IDENTIFIER, Pos: 3, Val: "b"},
+		{Typ: EOF, Pos: 4, Val: ""},
+	}},
+}
+
+func TestFilter(t *testing.T) {
+	for _, test := range filterTests {
+		items := drain(New(test.name, "a !b", lexSpaced, test.opts...))
+		if !equal(items, test.items, true) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+func TestFilterTrivia(t *testing.T) {
+	const input = "a  b"
+	s := New("filter", input, lexSpaced, Filter(notType(SPACE)), CollectTrivia(SPACE))
+	items := drain(s)
+	if len(items) != 3 {
+		t.Fatalf("got %d items, expected 3: %v", len(items), items)
+	}
+	trivia := items[1].LeadingTrivia()
+	if len(trivia) != 1 || trivia[0] != (Item{Typ: SPACE, Pos: 1, Val: "  "}) {
+		t.Errorf("trivia: got %v", trivia)
+	}
+	if err := VerifyRoundTrip(input, items); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	upper := func(item Item) Item {
+		if item.Typ == IDENTIFIER {
+			item.Val = strings.ToUpper(item.Val)
+		}
+		return item
+	}
+	retype := func(item Item) Item {
+		if item.Typ == IDENTIFIER && item.Val == "B" {
+			item.Typ = INT
+		}
+		return item
+	}
+	items := drain(New("map", "a b", lexSpaced, Map(upper), Filter(notType(SPACE)), Map(retype)))
+	expect := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "A"},
+		{Typ: INT, Pos: 2, Val: "B"},
+		{Typ: EOF, Pos: 3, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldRune returns the canonical representative of the runes equivalent to
+// r under Unicode simple case folding: the smallest rune of its orbit.
+func foldRune(r rune) rune {
+	lowest := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < lowest {
+			lowest = f
+		}
+	}
+	return lowest
+}
+
+// foldString maps each rune of s to its canonical representative under
+// case folding, so that strings equal under case folding map to the same
+// string.
+func foldString(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// AcceptFold consumes the next rune if it's from the valid set, ignoring
+// case under Unicode case folding.
+func (s *Scanner) AcceptFold(valid string) bool {
+	r := s.Next()
+	if r != EOF {
+		f := foldRune(r)
+		for _, v := range valid {
+			if foldRune(v) == f {
+				return true
+			}
+		}
+	}
+	s.Backup()
+	return false
+}
+
+// AcceptStringFold consumes str if the input continues with it, ignoring
+// case under Unicode case folding, and reports whether it did. The
+// consumed input may differ in length from str.
+func (s *Scanner) AcceptStringFold(str string) bool {
+	p := int(s.pos)
+	for _, r := range str {
+		if p >= len(s.input) {
+			return false
+		}
+		c, w := utf8.DecodeRuneInString(s.input[p:])
+		if foldRune(c) != foldRune(r) {
+			return false
+		}
+		p += w
+	}
+	s.pos = Pos(p)
+	s.width = 0
+	return true
+}
@@ -0,0 +1,50 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+type foldTest struct {
+	str   string
+	input string
+	ok    bool
+	rest  string
+}
+
+var acceptStringFoldTests = []foldTest{
+	{"select", "SELECT *", true, " *"},
+	{"select", "SeLeCt", true, ""},
+	{"select", "selec", false, "selec"},
+	{"straße", "STRAßE", true, ""},
+	{"k", "K!", true, "!"}, // KELVIN SIGN
+	{"from", "form", false, "form"},
+}
+
+func TestAcceptStringFold(t *testing.T) {
+	for _, test := range acceptStringFoldTests {
+		s := &Scanner{input: test.input}
+		ok := s.AcceptStringFold(test.str)
+		if ok != test.ok || s.input[s.pos:] != test.rest {
+			t.Errorf("AcceptStringFold(%q) on %q: got %v, rest %q, expected %v, %q", test.str, test.input, ok, s.input[s.pos:], test.ok, test.rest)
+		}
+	}
+}
+
+var acceptFoldTests = []foldTest{
+	{"abc", "B", true, ""},
+	{"abc", "d", false, "d"},
+	{"äö", "Ö", true, ""},
+	{"abc", "", false, ""},
+}
+
+func TestAcceptFold(t *testing.T) {
+	for _, test := range acceptFoldTests {
+		s := &Scanner{input: test.input}
+		ok := s.AcceptFold(test.str)
+		if ok != test.ok || s.input[s.pos:] != test.rest {
+			t.Errorf("AcceptFold(%q) on %q: got %v, rest %q, expected %v, %q", test.str, test.input, ok, s.input[s.pos:], test.ok, test.rest)
+		}
+	}
+}
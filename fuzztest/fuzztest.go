@@ -0,0 +1,105 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fuzztest turns lexers written with package scan into Go native
+// fuzz targets that check invariants every lexer should satisfy: the scan
+// terminates, item positions lie within the input and do not go
+// backwards, and, optionally, the items reproduce the input.
+//
+// A fuzz test for a lexer is a few lines:
+//
+//	func FuzzLexer(f *testing.F) {
+//		fuzztest.Config{Start: lexStart}.Fuzz(f, "seed input", "another")
+//	}
+package fuzztest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/schulze/scan"
+)
+
+// Config describes the lexer under test and the invariants to check.
+type Config struct {
+	Start   scan.StateFn  // initial state of the lexer
+	Options []scan.Option // further options for scan.New
+
+	// Lossless requires the items, together with the trivia collected
+	// with the CollectTrivia option of type TriviaType, to reproduce the
+	// input, as checked by scan.VerifyRoundTrip.
+	Lossless   bool
+	TriviaType scan.ItemType
+
+	// Timeout bounds the duration of a scan; the default is 10 seconds.
+	Timeout time.Duration
+	// MaxItems bounds the number of items of a scan; the default is ten
+	// items per byte of input, plus 100.
+	MaxItems int
+}
+
+// Fuzz adds the seeds to the corpus of f and runs Check on the inputs of
+// the fuzzer.
+func (c Config) Fuzz(f *testing.F, seeds ...string) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		c.Check(t, input)
+	})
+}
+
+// Check scans input and reports violated invariants to t.
+func (c Config) Check(t testing.TB, input string) {
+	t.Helper()
+	opts := append([]scan.Option{scan.Debug()}, c.Options...)
+	if c.Lossless {
+		opts = append(opts, scan.CollectTrivia(c.TriviaType))
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	maxItems := c.MaxItems
+	if maxItems <= 0 {
+		maxItems = 10*len(input) + 100
+	}
+	deadline := time.Now().Add(timeout)
+	s := scan.New("fuzz", input, c.Start, opts...)
+	var items []scan.Item
+	last := scan.Pos(0)
+	for {
+		item, err := s.NextItemTimeout(time.Until(deadline))
+		if err != nil {
+			t.Fatalf("scan of %q did not finish within %v", input, timeout)
+		}
+		items = append(items, item)
+		if item.Pos < 0 || int(item.Pos) > len(input) {
+			t.Errorf("scan of %q: position of item %d %v out of range", input, len(items)-1, item)
+		}
+		switch item.Typ {
+		case scan.EOF:
+			if c.Lossless {
+				if err := scan.VerifyRoundTrip(input, items); err != nil {
+					t.Errorf("scan of %q: %v", input, err)
+				}
+			}
+			return
+		case scan.ERROR:
+			if errors.Is(item.Err(), scan.ErrNoProgress) {
+				t.Fatalf("scan of %q: %v", input, item)
+			}
+		case scan.WARNING:
+		default:
+			if item.Pos < last {
+				t.Errorf("scan of %q: item %d %v goes back from position %d", input, len(items)-1, item, last)
+			}
+			last = item.Pos
+		}
+		if len(items) > maxItems {
+			t.Fatalf("scan of %q: more than %d items", input, maxItems)
+		}
+	}
+}
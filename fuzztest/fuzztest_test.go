@@ -0,0 +1,98 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzztest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/schulze/scan"
+)
+
+const (
+	WORD = iota
+	SPACE
+)
+
+// lexWords scans words separated by spaces.
+func lexWords(s *scan.Scanner) scan.StateFn {
+	switch r := s.Peek(); {
+	case r == scan.EOF:
+		s.Emit(scan.EOF)
+		return nil
+	case r == ' ':
+		s.AcceptRun(" ")
+		s.Ignore()
+	default:
+		for r := s.Next(); r != ' ' && r != scan.EOF; r = s.Next() {
+		}
+		s.Backup()
+		s.Emit(WORD)
+	}
+	return lexWords
+}
+
+// lexStall never makes progress.
+func lexStall(s *scan.Scanner) scan.StateFn {
+	return lexStall
+}
+
+// recorder records the failures reported by Check.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+type checkTest struct {
+	name   string
+	config Config
+	fail   string
+}
+
+var checkTests = []checkTest{
+	{"ok", Config{Start: lexWords}, ""},
+	{"lossless", Config{Start: lexWords, Lossless: true, TriviaType: SPACE}, ""},
+	{"duplicating", Config{Start: lexWords, Lossless: true, TriviaType: SPACE, Options: []scan.Option{
+		scan.Map(func(item scan.Item) scan.Item {
+			if item.Val == "b" {
+				item.Val = "bb"
+			}
+			return item
+		}),
+	}}, "reconstructed input diverges"},
+	{"stalling", Config{Start: lexStall}, "no progress"},
+	{"backwards", Config{Start: lexWords, Options: []scan.Option{scan.Map(func(item scan.Item) scan.Item {
+		if item.Val == "c" {
+			item.Pos = 0
+		}
+		return item
+	})}}, "goes back"},
+}
+
+func TestCheck(t *testing.T) {
+	for _, test := range checkTests {
+		r := &recorder{TB: t}
+		test.config.Check(r, "a b  c")
+		got := strings.Join(r.failures, "\n")
+		if test.fail == "" && got != "" || !strings.Contains(got, test.fail) {
+			t.Errorf("%s: got failures %q, expected %q", test.name, got, test.fail)
+		}
+	}
+}
+
+func FuzzWords(f *testing.F) {
+	Config{Start: lexWords, Lossless: true, TriviaType: SPACE}.Fuzz(f, "a b", "  x  ", "")
+}
@@ -0,0 +1,71 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// A TokenFile converts positions in the input of a scanner into positions
+// of the go/token package, so that lexers built on this package can be used
+// with tools that work with token.FileSet.
+type TokenFile struct {
+	File *token.File // the input as registered in the file set
+}
+
+// AddToFileSet registers the input of s as a file in fset and returns an
+// adapter for converting its positions. The lines of the file and the
+// sources of text inserted with Include are recorded in the file, as are
+// the lines following line directives with the LineDirectives option, so
+// that fset reports the same file names and lines as Position; columns
+// are counted in bytes, as usual for go/token. AddToFileSet should be
+// called after the scan has finished, when the input can no longer
+// change.
+func (s *Scanner) AddToFileSet(fset *token.FileSet) *TokenFile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f := fset.AddFile(s.name, -1, len(s.input))
+	lines := s.newlines.lineStarts(s.input)
+	f.SetLines(lines)
+	// Record the sources at the starts of segments and after directives.
+	var starts []int
+	for i, seg := range s.segments {
+		if i+1 < len(s.segments) && s.segments[i+1].start == seg.start {
+			continue // empty segment
+		}
+		starts = append(starts, int(seg.start))
+	}
+	if s.directives {
+		for _, start := range lines[1:] {
+			_, prev := s.newlines.prevLine(s.input, start)
+			if _, _, ok := parseLineDirective(strings.TrimSuffix(prev, "\r")); ok {
+				starts = append(starts, start)
+			}
+		}
+		slices.Sort(starts)
+		starts = slices.Compact(starts)
+	}
+	for _, start := range starts {
+		name, text, offset := s.sourceLocked(Pos(start))
+		line, lineStart := s.newlines.lineOf(text, offset)
+		if s.directives {
+			name, line = s.applyLineDirective(text, lineStart, name, line)
+		}
+		f.AddLineColumnInfo(start, name, line, 1+offset-lineStart)
+	}
+	return &TokenFile{File: f}
+}
+
+// Pos returns the token.Pos corresponding to p.
+func (f *TokenFile) Pos(p Pos) token.Pos {
+	return f.File.Pos(int(p))
+}
+
+// Position returns the token.Position corresponding to p.
+func (f *TokenFile) Position(p Pos) token.Position {
+	return f.File.Position(f.Pos(p))
+}
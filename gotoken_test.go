@@ -0,0 +1,49 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"go/token"
+	"testing"
+)
+
+func TestAddToFileSet(t *testing.T) {
+	fset := token.NewFileSet()
+	fset.AddFile("other", -1, 10) // make sure the base is not 1
+	s := New("main", "a @two\nb", lexIncludes)
+	items := drain(s)
+	s.NextItem() // wait for the state machine to finish
+	f := s.AddToFileSet(fset)
+	expected := []string{
+		"main:1:1",
+		"two:2:1",
+		"one:1:1",
+		"one:2:3",
+		"main:2:1",
+		"main:2:2",
+	}
+	for i, item := range items {
+		if got := fset.Position(f.Pos(item.Pos)).String(); got != expected[i] {
+			t.Errorf("%v: got %s, expected %s", item, got, expected[i])
+		}
+		if got := f.Position(item.Pos).String(); got != expected[i] {
+			t.Errorf("%v: got %s from Position, expected %s", item, got, expected[i])
+		}
+	}
+}
+
+func TestAddToFileSetLineDirectives(t *testing.T) {
+	const input = "a\n//line gen.y:10\nb\n\n#line 3 \"orig.c\"\nc d"
+	s := New("src", input, nil, LineDirectives())
+	f := s.AddToFileSet(token.NewFileSet())
+	for _, p := range []Pos{0, 18, 20, 38, 40} {
+		pos := s.Position(p)
+		expected := fmt.Sprintf("%s:%d:%d", pos.Name, pos.Line, pos.Column)
+		if got := f.Position(p).String(); got != expected {
+			t.Errorf("%d: got %s, expected %s", p, got, expected)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "strconv"
+
+// A Class is a syntax-highlighting class.
+type Class int
+
+// Highlighting classes.
+const (
+	ClassText Class = iota // plain text, including ignored input such as white space
+	ClassKeyword
+	ClassIdentifier
+	ClassString
+	ClassNumber
+	ClassComment
+	ClassOperator
+	ClassPunctuation
+	ClassInvalid // error items
+)
+
+var classNames = [...]string{
+	ClassText:        "text",
+	ClassKeyword:     "keyword",
+	ClassIdentifier:  "identifier",
+	ClassString:      "string",
+	ClassNumber:      "number",
+	ClassComment:     "comment",
+	ClassOperator:    "operator",
+	ClassPunctuation: "punctuation",
+	ClassInvalid:     "invalid",
+}
+
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return "class" + strconv.Itoa(int(c))
+	}
+	return classNames[c]
+}
+
+// A Span is a highlighted part of the input.
+type Span struct {
+	Start, End Pos // the span covers the input between Start and End
+	Class      Class
+}
+
+// A Highlighter turns the items of a lexer into highlighted spans, as
+// consumed by syntax highlighters in the style of chroma or TextMate.
+type Highlighter struct {
+	Classes map[ItemType]Class // classes of the item types; other types are ClassText
+}
+
+// Highlight reads the items of s up to EOF and returns spans covering the
+// whole input in order. Input not covered by any item, such as ignored
+// white space, is returned as ClassText spans, and an error item is
+// returned as a ClassInvalid span extending to the next item. Adjacent
+// spans of the same class are merged.
+func (h *Highlighter) Highlight(s *Scanner) []Span {
+	var spans []Span
+	add := func(start, end Pos, c Class) {
+		if start >= end {
+			return
+		}
+		if n := len(spans); n > 0 && spans[n-1].Class == c && spans[n-1].End == start {
+			spans[n-1].End = end
+			return
+		}
+		spans = append(spans, Span{start, end, c})
+	}
+	var errPos Pos = -1
+	var p Pos
+	for {
+		item := s.NextItem()
+		if errPos >= 0 {
+			add(errPos, item.Pos, ClassInvalid)
+			p, errPos = item.Pos, -1
+		}
+		switch item.Typ {
+		case EOF:
+			s.NextItem() // wait for the scan to finish before reading the input
+			s.mu.RLock()
+			add(p, Pos(len(s.input)), ClassText)
+			s.mu.RUnlock()
+			return spans
+		case ERROR:
+			add(p, item.Pos, ClassText)
+			p, errPos = item.Pos, item.Pos
+			continue
+		}
+		add(p, item.Pos, ClassText)
+		p = item.End()
+		add(item.Pos, p, h.Classes[item.Typ])
+	}
+}
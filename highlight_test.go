@@ -0,0 +1,29 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	h := &Highlighter{Classes: map[ItemType]Class{
+		IDENTIFIER: ClassIdentifier,
+		INTEGER:    ClassNumber,
+		PLUS:       ClassOperator,
+	}}
+	input := "ab + 12 ?? c"
+	spans := h.Highlight(New("highlight", input, lexTokens))
+	var out []string
+	for _, span := range spans {
+		out = append(out, fmt.Sprintf("%s%q", span.Class, input[span.Start:span.End]))
+	}
+	expected := `identifier"ab" text" " operator"+" text" " number"12" text" " invalid"?? " identifier"c"`
+	if got := strings.Join(out, " "); got != expected {
+		t.Errorf("got\n\t%s\nexpected\n\t%s", got, expected)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// KeepHistory makes the scanner remember the last k items emitted by the
+// lexer for History. By default only the last item is kept.
+func KeepHistory(k int) Option {
+	return func(s *Scanner) {
+		s.historySize = max(k, 1)
+	}
+}
+
+// record adds item to the history.
+func (s *Scanner) record(item Item) {
+	if len(s.history) >= max(s.historySize, 1) {
+		copy(s.history, s.history[1:])
+		s.history = s.history[:len(s.history)-1]
+	}
+	s.history = append(s.history, item)
+}
+
+// LastItem returns the last item emitted by the lexer, not counting error
+// and warning items, and whether there was one. It lets state functions
+// take decisions that depend on the preceding token, such as whether a '/'
+// starts a regular expression or is a division operator, and error
+// messages say "after" which token they occurred. Items are recorded as
+// emitted, before Filter and Map.
+func (s *Scanner) LastItem() (Item, bool) {
+	if len(s.history) == 0 {
+		return Item{}, false
+	}
+	return s.history[len(s.history)-1], true
+}
+
+// History returns up to the last k items emitted by the lexer, as set with
+// KeepHistory, oldest first. The result must not be modified.
+func (s *Scanner) History() []Item {
+	return s.history
+}
@@ -0,0 +1,76 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+const (
+	DIV = iota + 800
+)
+
+// lexSlash scans identifiers and slashes, which are divisions after an
+// identifier and comments elsewhere.
+func lexSlash(s *Scanner) StateFn {
+	switch r := s.Next(); {
+	case r == EOF:
+		s.Emit(EOF)
+		return nil
+	case r == ' ':
+		s.Ignore()
+	case r == '/':
+		if last, ok := s.LastItem(); ok && last.Typ == IDENTIFIER {
+			s.Emit(DIV)
+		} else {
+			s.Emit(COMMENT)
+		}
+	default:
+		s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		s.Emit(IDENTIFIER)
+	}
+	return lexSlash
+}
+
+func TestLastItem(t *testing.T) {
+	items := drain(New("history", "/ a / b", lexSlash, Filter(notType(IDENTIFIER))))
+	expect := []Item{
+		{Typ: COMMENT, Pos: 0, Val: "/"},
+		{Typ: DIV, Pos: 4, Val: "/"},
+		{Typ: EOF, Pos: 7, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var got [][]Item
+	var lex StateFn
+	lex = func(s *Scanner) StateFn {
+		next := lexSlash(s)
+		got = append(got, append([]Item(nil), s.History()...))
+		if next == nil {
+			return nil
+		}
+		return lex
+	}
+	s := New("history", "a/b", lex, KeepHistory(2))
+	drain(s)
+	s.NextItem() // wait for the scan to finish
+
+	expect := [][]Item{
+		{{Typ: IDENTIFIER, Pos: 0, Val: "a"}},
+		{{Typ: IDENTIFIER, Pos: 0, Val: "a"}, {Typ: DIV, Pos: 1, Val: "/"}},
+		{{Typ: DIV, Pos: 1, Val: "/"}, {Typ: IDENTIFIER, Pos: 2, Val: "b"}},
+		{{Typ: IDENTIFIER, Pos: 2, Val: "b"}, {Typ: EOF, Pos: 3, Val: ""}},
+	}
+	if len(got) != len(expect) {
+		t.Fatalf("got %d states, expected %d: %v", len(got), len(expect), got)
+	}
+	for i := range got {
+		if !equal(got[i], expect[i], true) {
+			t.Errorf("%d: got\n\t%+v\nexpected\n\t%v", i, got[i], expect[i])
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "sort"
+
+// A segment is a part of the input that comes from a single source.
+type segment struct {
+	start  Pos    // position of the segment in the input
+	name   string // name of the source
+	text   string // text of the whole source
+	offset int    // offset of the segment in text
+}
+
+// Include inserts text, the contents of the source called name, into the
+// input at the current position, as a preprocessor does for an include
+// directive. Scanning continues with the included text; when it is
+// exhausted, scanning returns to the rest of the including input without
+// an intervening EOF. Position reports positions in the included text
+// relative to the included source. Include is usually called after the
+// directive has been consumed and ignored.
+func (s *Scanner) Include(name, text string) {
+	if s.newlines == NewlineNormalize {
+		text = normalizeNewlines(text)
+	}
+	s.mu.Lock()
+	p := s.pos
+	if s.segments == nil {
+		s.segments = []segment{{start: 0, name: s.name, text: s.input}}
+	}
+	k := s.segmentAt(p)
+	seg := s.segments[k]
+	rest := segment{start: p, name: seg.name, text: seg.text, offset: seg.offset + int(p-seg.start)}
+	segments := append([]segment{}, s.segments[:k+1]...)
+	segments = append(segments, segment{start: p, name: name, text: text}, rest)
+	segments = append(segments, s.segments[k+1:]...)
+	for i := k + 2; i < len(segments); i++ {
+		segments[i].start += Pos(len(text))
+	}
+	s.segments = segments
+	s.input = s.input[:p] + text + s.input[p:]
+	s.mu.Unlock()
+	s.checkInputLen()
+}
+
+// segmentAt returns the index of the last segment starting at or before p.
+func (s *Scanner) segmentAt(p Pos) int {
+	return sort.Search(len(s.segments), func(i int) bool { return s.segments[i].start > p }) - 1
+}
+
+// source returns the name and text of the source that position p in the
+// input comes from, and the offset of p in that text.
+func (s *Scanner) source(p Pos) (name, text string, offset int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sourceLocked(p)
+}
+
+// sourceLocked is source for callers holding s.mu.
+func (s *Scanner) sourceLocked(p Pos) (name, text string, offset int) {
+	if s.segments == nil {
+		return s.name, s.input, int(p)
+	}
+	seg := s.segments[s.segmentAt(p)]
+	return seg.name, seg.text, seg.offset + int(p-seg.start)
+}
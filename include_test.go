@@ -0,0 +1,54 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+var includes = map[string]string{
+	"one": "x\n  y",
+	"two": "\nz @one",
+}
+
+// lexIncludes scans words separated by white space. A word starting with
+// '@' includes the source of that name.
+func lexIncludes(s *Scanner) StateFn {
+	switch r := s.Next(); {
+	case r == EOF:
+		s.Emit(EOF)
+		return nil
+	case r == ' ' || r == '\n':
+		s.Ignore()
+	case r == '@':
+		s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		name := s.Text()[1:]
+		s.Ignore()
+		s.Include(name, includes[name])
+	default:
+		s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		s.Emit(IDENTIFIER)
+	}
+	return lexIncludes
+}
+
+func TestInclude(t *testing.T) {
+	s := New("main", "a @two\nb", lexIncludes)
+	items := drain(s)
+	expected := []string{
+		"main:1:1 \"a\"",
+		"two:2:1 \"z\"",
+		"one:1:1 \"x\"",
+		"one:2:3 \"y\"",
+		"main:2:1 \"b\"",
+		"main:2:2 EOF",
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("got %v, expected %d items", items, len(expected))
+	}
+	for i, item := range items {
+		if got := s.Position(item.Pos).String() + " " + item.String(); got != expected[i] {
+			t.Errorf("got %q, expected %q", got, expected[i])
+		}
+	}
+}
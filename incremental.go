@@ -0,0 +1,254 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// An Edit describes a change of the input: Deleted bytes at Offset are
+// replaced by Inserted.
+type Edit struct {
+	Offset   int
+	Deleted  int
+	Inserted string
+}
+
+// Incremental keeps the items of an input up to date while the input is
+// edited, as editors and language servers need on every keystroke. Instead
+// of scanning the whole input again, Apply re-scans only the region
+// damaged by an edit and splices the result into the items.
+//
+// While scanning, Incremental records the state of the lexer at the item
+// boundaries. Apply restarts the lexer at the last recorded boundary
+// before the edit and stops as soon as, behind the edit, the lexer reaches
+// a boundary with the same state as in the previous scan: the same state
+// function, saved states, nesting depth and depths of brackets after the
+// same last item. The rest of the items are then those of the previous
+// scan, moved by the change in length. This assumes that what a state
+// function does depends only on that state and on the input from the
+// boundary on, looking at most one rune ahead of the items it emits. State
+// functions are compared by their code, so closures created by the same
+// function literal count as the same state. Since the messages of error
+// and warning items may refer to lines and columns, Apply does not stop
+// before the last of them.
+//
+// The lexer runs synchronously on the calling goroutine. Options that
+// configure lexing, such as Newlines, apply; options about passing items
+// to a client, such as ItemBuffer, MaxErrors or Tee, have no effect.
+type Incremental struct {
+	name   string
+	input  string
+	start  StateFn
+	opts   []Option
+	items  []Item
+	bounds []boundary // item boundaries, in order of position
+}
+
+// A boundary is a lexer state recorded between two state functions with
+// no pending text.
+type boundary struct {
+	pos     Pos
+	state   StateFn
+	stack   []StateFn
+	nesting int
+	depths  map[ItemType]int
+	items   int // number of items emitted before the boundary
+}
+
+// NewIncremental scans input, starting with state start, and returns an
+// Incremental holding the items.
+func NewIncremental(name, input string, start StateFn, opts ...Option) *Incremental {
+	inc := &Incremental{name: name, input: input, start: start, opts: opts}
+	s := inc.scanner(input, boundary{state: start})
+	inc.items, inc.bounds, _ = inc.run(s, Pos(len(input)+1), nil)
+	return inc
+}
+
+// Input returns the current input.
+func (inc *Incremental) Input() string {
+	return inc.input
+}
+
+// Items returns the items of the current input, up to and including EOF.
+// The result must not be modified.
+func (inc *Incremental) Items() []Item {
+	return inc.items
+}
+
+// Apply applies the edit e to the input and updates the items. It returns
+// the range of items that changed: the items in [from, oldEnd) before the
+// edit were replaced by those in [from, newEnd) of Items. Apply panics if
+// the edit lies outside the input.
+func (inc *Incremental) Apply(e Edit) (from, oldEnd, newEnd int) {
+	if e.Offset < 0 || e.Deleted < 0 || e.Offset+e.Deleted > len(inc.input) {
+		panic(fmt.Sprintf("scan: Edit [%d, %d) out of range [0, %d]", e.Offset, e.Offset+e.Deleted, len(inc.input)))
+	}
+	input := inc.input[:e.Offset] + e.Inserted + inc.input[e.Offset+e.Deleted:]
+	delta := Pos(len(e.Inserted) - e.Deleted)
+
+	// Restart at the last boundary before the edit, so that the item
+	// ending at the edit, if any, is scanned again.
+	i := sort.Search(len(inc.bounds), func(i int) bool { return int(inc.bounds[i].pos) >= e.Offset }) - 1
+	restart := boundary{state: inc.start}
+	if i >= 0 {
+		restart = inc.bounds[i]
+	} else {
+		i = 0
+	}
+	s := inc.scanner(input, restart)
+	if restart.items > 0 {
+		s.history = []Item{inc.items[restart.items-1]}
+	}
+
+	// Stop at a boundary behind the edit and the last error or warning
+	// that matches a boundary of the previous scan.
+	lastDiag := -1
+	for k, item := range inc.items {
+		if item.Typ == ERROR || item.Typ == WARNING {
+			lastDiag = k
+		}
+	}
+	var old int
+	sync := func(b boundary, last Item) bool {
+		j := sort.Search(len(inc.bounds), func(j int) bool { return inc.bounds[j].pos >= b.pos-delta })
+		if j == len(inc.bounds) || inc.bounds[j].items <= lastDiag || !inc.bounds[j].matches(b, delta) {
+			return false
+		}
+		if n := inc.bounds[j].items; n > 0 && (inc.items[n-1].Typ != last.Typ || inc.items[n-1].Val != last.Val) {
+			return false
+		}
+		old = j
+		return true
+	}
+	items, bounds, synced := inc.run(s, Pos(e.Offset+len(e.Inserted)), sync)
+
+	from = restart.items
+	newEnd = from + len(items)
+	if synced {
+		oldEnd = inc.bounds[old].items
+		shift := newEnd - oldEnd
+		for _, item := range inc.items[oldEnd:] {
+			items = append(items, item.moved(delta))
+		}
+		for _, b := range inc.bounds[old+1:] {
+			b.pos += delta
+			b.items += shift
+			bounds = append(bounds, b)
+		}
+	} else {
+		oldEnd = len(inc.items)
+	}
+	inc.input = input
+	inc.items = append(inc.items[:from:from], items...)
+	inc.bounds = append(inc.bounds[:i:i], bounds...)
+	return from, oldEnd, newEnd
+}
+
+// moved returns the item and its trivia moved by delta.
+func (i Item) moved(delta Pos) Item {
+	i.Pos += delta
+	if i.end > 0 {
+		i.end += delta
+	}
+	if i.trivia != nil {
+		trivia := make([]Item, len(*i.trivia))
+		for k, t := range *i.trivia {
+			trivia[k] = t.moved(delta)
+		}
+		i.trivia = &trivia
+	}
+	return i
+}
+
+// matches reports whether the boundary b of a new scan, with positions
+// moved by delta, has the same lexer state as a.
+func (a boundary) matches(b boundary, delta Pos) bool {
+	if a.pos+delta != b.pos || a.nesting != b.nesting || !maps.Equal(a.depths, b.depths) || len(a.stack) != len(b.stack) || !sameState(a.state, b.state) {
+		return false
+	}
+	for k := range a.stack {
+		if !sameState(a.stack[k], b.stack[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameState reports whether two state functions have the same code.
+func sameState(f, g StateFn) bool {
+	if f == nil || g == nil {
+		return f == nil && g == nil
+	}
+	return stateKey(f) == stateKey(g)
+}
+
+// scanner returns a scanner for input starting at boundary b, with the
+// items passed to a sink set by run.
+func (inc *Incremental) scanner(input string, b boundary) *Scanner {
+	s := &Scanner{
+		name:    inc.name,
+		input:   input,
+		state:   b.state,
+		pos:     b.pos,
+		start:   b.pos,
+		lastPos: b.pos,
+		stack:   append([]StateFn(nil), b.stack...),
+		nesting: b.nesting,
+		depths:  maps.Clone(b.depths),
+		emitted: b.items,
+	}
+	for _, opt := range inc.opts {
+		opt(s)
+	}
+	if s.skipBOM && s.pos == 0 {
+		s.skipLeadingBOM()
+	}
+	return s
+}
+
+// run runs the state machine of s and returns the items it emits and the
+// boundaries it passes, starting with the one it starts at. If sync is not
+// nil, run stops at the first boundary at or after position after for
+// which sync returns true, given the last item emitted, and reports true.
+func (inc *Incremental) run(s *Scanner, after Pos, sync func(b boundary, last Item) bool) ([]Item, []boundary, bool) {
+	var items []Item
+	s.sink = func(item Item) {
+		items = append(items, item)
+	}
+	bounds := []boundary{s.boundary()}
+	for s.state != nil && !s.stopped {
+		s.state = s.state(s)
+		if s.start != s.pos || s.pos <= bounds[len(bounds)-1].pos {
+			continue
+		}
+		b := s.boundary()
+		if sync != nil && b.pos >= after {
+			last, _ := s.LastItem()
+			if sync(b, last) {
+				return items, append(bounds, b), true
+			}
+		}
+		bounds = append(bounds, b)
+	}
+	if len(items) == 0 || items[len(items)-1].Typ != EOF {
+		items = append(items, Item{Typ: EOF, Pos: Pos(len(s.input))})
+	}
+	return items, bounds, false
+}
+
+// boundary returns the current state of s as a boundary.
+func (s *Scanner) boundary() boundary {
+	return boundary{
+		pos:     s.pos,
+		state:   s.state,
+		stack:   append([]StateFn(nil), s.stack...),
+		nesting: s.nesting,
+		depths:  maps.Clone(s.depths),
+		items:   s.emitted,
+	}
+}
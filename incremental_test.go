@@ -0,0 +1,79 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIncremental(t *testing.T) {
+	input := strings.Repeat("abc + (* x (* y *) *) 12 - (d)\n", 50)
+	inc := NewIncremental("inc", input, lexStart)
+	if want := drain(New("full", input, lexStart)); !equal(inc.Items(), want, true) {
+		t.Fatalf("initial scan: got\n\t%+v\nexpected\n\t%v", inc.Items(), want)
+	}
+	from, oldEnd, newEnd := inc.Apply(Edit{Offset: 33, Deleted: 2, Inserted: "xy z"})
+	if from != 7 || oldEnd != 9 || newEnd != 10 {
+		t.Errorf("changed range: got [%d, %d) -> [%d, %d), expected [7, 9) -> [7, 10)", from, oldEnd, from, newEnd)
+	}
+	if want := drain(New("full", inc.Input(), lexStart)); !equal(inc.Items(), want, true) {
+		t.Fatalf("after edit: got\n\t%+v\nexpected\n\t%v", inc.Items(), want)
+	}
+}
+
+// TestIncrementalRandom applies random edits and compares the items with
+// those of a full scan. It uses lexBlock, since the edits leave comments
+// unterminated.
+func TestIncrementalRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const alphabet = "ab1 (*)+-\n"
+	random := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	inc := NewIncremental("random", random(200), lexBlock)
+	for i := 0; i < 500; i++ {
+		offset := rng.Intn(len(inc.Input()) + 1)
+		e := Edit{
+			Offset:   offset,
+			Deleted:  rng.Intn(len(inc.Input())-offset+1) % 5,
+			Inserted: random(rng.Intn(4)),
+		}
+		before := inc.Input()
+		inc.Apply(e)
+		if want := drain(New("full", inc.Input(), lexBlock)); !equal(inc.Items(), want, true) {
+			t.Fatalf("edit %+v of %q: got\n\t%+v\nexpected\n\t%v", e, before, inc.Items(), want)
+		}
+	}
+}
+
+func TestIncrementalTrivia(t *testing.T) {
+	inc := NewIncremental("trivia", "aa bb cc dd", lexStart, CollectTrivia(SPACE))
+	inc.Apply(Edit{Offset: 0, Inserted: "zzzz"})
+	want := drain(New("full", inc.Input(), lexStart, CollectTrivia(SPACE)))
+	if !equal(inc.Items(), want, true) {
+		t.Fatalf("got\n\t%+v\nexpected\n\t%v", inc.Items(), want)
+	}
+	for k, item := range inc.Items() {
+		if !reflect.DeepEqual(item.LeadingTrivia(), want[k].LeadingTrivia()) {
+			t.Errorf("%v: got trivia %+v, expected %+v", item, item.LeadingTrivia(), want[k].LeadingTrivia())
+		}
+	}
+}
+
+func TestIncrementalEditOutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic")
+		}
+	}()
+	NewIncremental("range", "abc", lexStart).Apply(Edit{Offset: 2, Deleted: 2})
+}
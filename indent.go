@@ -0,0 +1,86 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// indentation holds the state of the indentation subsystem.
+type indentation struct {
+	enabled  bool
+	tabWidth int
+	indent   ItemType // type of the items emitted when the indentation increases
+	dedent   ItemType // type of the items emitted when the indentation decreases
+	levels   []int    // stack of open indentation widths, excluding the outermost 0
+}
+
+// Indentation enables the indentation subsystem for languages using the
+// off-side rule. A lexer calls ScanIndent at the start of each line and
+// DedentAll at the end of the input; the scanner then emits an item of type
+// indent whenever a line is indented further than the enclosing block, and
+// one item of type dedent for each block closed by a line indented less.
+// When measuring indentation, a tab advances to the next multiple of
+// tabWidth; a tabWidth of 0 or less means 8.
+func Indentation(tabWidth int, indent, dedent ItemType) Option {
+	return func(s *Scanner) {
+		if tabWidth <= 0 {
+			tabWidth = 8
+		}
+		s.indent = indentation{enabled: true, tabWidth: tabWidth, indent: indent, dedent: dedent}
+	}
+}
+
+// ScanIndent consumes the spaces and tabs at the start of a line and emits
+// indent and dedent items as described for the Indentation option. Blank
+// lines do not affect the indentation. A line whose indentation does not
+// match any enclosing block is an error. ScanIndent panics if the
+// Indentation option is not set.
+func (s *Scanner) ScanIndent() {
+	if !s.indent.enabled {
+		panic("scan: ScanIndent called without the Indentation option")
+	}
+	width := 0
+	for r := s.Peek(); r == ' ' || r == '\t'; r = s.Peek() {
+		if r == '\t' {
+			width += s.indent.tabWidth - width%s.indent.tabWidth
+		} else {
+			width++
+		}
+		s.Next()
+	}
+	if r := s.Peek(); r == '\n' || r == '\r' || r == EOF {
+		s.Ignore() // blank line
+		return
+	}
+	levels := s.indent.levels
+	current := 0
+	if len(levels) > 0 {
+		current = levels[len(levels)-1]
+	}
+	switch {
+	case width > current:
+		s.indent.levels = append(levels, width)
+		s.Emit(s.indent.indent)
+	case width < current:
+		s.Ignore()
+		for len(levels) > 0 && levels[len(levels)-1] > width {
+			levels = levels[:len(levels)-1]
+			s.Emit(s.indent.dedent)
+		}
+		s.indent.levels = levels
+		if len(levels) > 0 && levels[len(levels)-1] != width || len(levels) == 0 && width != 0 {
+			s.EmitError("inconsistent indentation at %s", s.lineCol(s.pos))
+		}
+	default:
+		s.Ignore()
+	}
+}
+
+// DedentAll emits a dedent item for each open indented block. It is called
+// at the end of the input, before emitting EOF.
+func (s *Scanner) DedentAll() {
+	s.Ignore()
+	for range s.indent.levels {
+		s.Emit(s.indent.dedent)
+	}
+	s.indent.levels = nil
+}
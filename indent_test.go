@@ -0,0 +1,76 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+const (
+	INDENT = iota + 500
+	DEDENT
+	NEWLINE
+)
+
+// lexLines scans lines of identifiers with significant indentation.
+func lexLines(s *Scanner) StateFn {
+	s.ScanIndent()
+	return lexLine
+}
+
+func lexLine(s *Scanner) StateFn {
+	switch r := s.Next(); {
+	case r == EOF:
+		s.DedentAll()
+		s.Emit(EOF)
+		return nil
+	case r == '\n':
+		s.Emit(NEWLINE)
+		return lexLines
+	case r == ' ':
+		s.Ignore()
+	default:
+		for isAlphaNumeric(s.Peek()) {
+			s.Next()
+		}
+		s.Emit(IDENTIFIER)
+	}
+	return lexLine
+}
+
+var (
+	tIndent  = Item{Typ: INDENT}
+	tDedent  = Item{Typ: DEDENT}
+	tNewline = Item{Typ: NEWLINE, Val: "\n"}
+)
+
+func TestIndentation(t *testing.T) {
+	tests := []lexTest{
+		{"blocks", "a\n  b\n\n    c\n  d\ne", []Item{
+			{Typ: IDENTIFIER, Val: "a"}, tNewline,
+			{Typ: INDENT, Val: "  "}, {Typ: IDENTIFIER, Val: "b"}, tNewline, tNewline,
+			{Typ: INDENT, Val: "    "}, {Typ: IDENTIFIER, Val: "c"}, tNewline,
+			tDedent, {Typ: IDENTIFIER, Val: "d"}, tNewline,
+			tDedent, {Typ: IDENTIFIER, Val: "e"},
+			tEOF,
+		}},
+		{"tabs and eof", "a\n\tb\n        c", []Item{
+			{Typ: IDENTIFIER, Val: "a"}, tNewline,
+			{Typ: INDENT, Val: "\t"}, {Typ: IDENTIFIER, Val: "b"}, tNewline,
+			{Typ: IDENTIFIER, Val: "c"},
+			tDedent, tEOF,
+		}},
+		{"inconsistent", "a\n    b\n  c", []Item{
+			{Typ: IDENTIFIER, Val: "a"}, tNewline,
+			{Typ: INDENT, Val: "    "}, {Typ: IDENTIFIER, Val: "b"}, tNewline,
+			tDedent, {Typ: ERROR, Val: "inconsistent indentation at 3:3"}, {Typ: IDENTIFIER, Val: "c"},
+			tEOF,
+		}},
+	}
+	for _, test := range tests {
+		items := drain(New(test.name, test.input, lexLines, Indentation(8, INDENT, DEDENT)))
+		if !equal(items, test.items, false) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "unique"
+
+// Intern makes the scanner intern the values of the items of the given
+// types, so that equal values share one string across the items of all
+// scanners, as identifiers and keywords repeated throughout large inputs
+// do. Interned values no longer refer to the input, which can then be
+// freed while the items are kept. If no types are given, the values of all
+// items but errors and warnings are interned.
+func Intern(types ...ItemType) Option {
+	return func(s *Scanner) {
+		s.addStage(func(item Item) (Item, bool) {
+			if internable(item.Typ, types) {
+				item.Val = unique.Make(item.Val).Value()
+			}
+			return item, true
+		})
+	}
+}
+
+// internable reports whether items of type t are interned for the types
+// passed to Intern.
+func internable(t ItemType, types []ItemType) bool {
+	if len(types) == 0 {
+		return t != ERROR && t != WARNING
+	}
+	for _, u := range types {
+		if t == u {
+			return true
+		}
+	}
+	return false
+}
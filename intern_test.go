@@ -0,0 +1,28 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestIntern(t *testing.T) {
+	const input = "abc 12 abc 12"
+	items := drain(New("intern", input, lexStart, Intern(IDENTIFIER)))
+	items = append(items, drain(New("intern", "x abc", lexStart, Intern()))...)
+	same := func(a, b string) bool {
+		return unsafe.StringData(a) == unsafe.StringData(b)
+	}
+	if !same(items[0].Val, items[2].Val) || !same(items[0].Val, items[6].Val) {
+		t.Errorf("identifiers %+v, %+v and %+v do not share their value", items[0], items[2], items[6])
+	}
+	if same(items[1].Val, items[3].Val) {
+		t.Errorf("integers %+v and %+v were interned", items[1], items[3])
+	}
+	if same(items[0].Val, input[:3]) {
+		t.Errorf("interned value %+v refers to the input", items[0])
+	}
+}
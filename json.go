@@ -0,0 +1,136 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonItem is the JSON representation of an item. The type is the name
+// given in TypeNames or registered with RegisterTypeName or, if there is
+// none, the number of the type.
+type jsonItem struct {
+	Typ json.RawMessage `json:"type"`
+	Pos Pos             `json:"pos"`
+	Val string          `json:"val"`
+	End *Pos            `json:"end,omitempty"`
+}
+
+// MarshalJSON encodes the item as a JSON object with the fields "type",
+// "pos" and "val". The type is given by its registered name if it has one,
+// and by its number otherwise. The field "end" is added for items whose end
+// differs from Pos+len(Val), such as those emitted by EmitTrimmed. As with
+// EncodeItems, the results of Value and LeadingTrivia are not encoded.
+func (i Item) MarshalJSON() ([]byte, error) {
+	return TypeNames(nil).marshal(i)
+}
+
+// marshal is MarshalJSON with the type names in n.
+func (n TypeNames) marshal(i Item) ([]byte, error) {
+	var typ []byte
+	if name, ok := n.lookup(i.Typ); ok {
+		typ, _ = json.Marshal(name)
+	} else {
+		typ, _ = json.Marshal(int(i.Typ))
+	}
+	j := jsonItem{Typ: typ, Pos: i.Pos, Val: i.Val}
+	if i.end != 0 {
+		end := i.End()
+		j.End = &end
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes an item encoded by MarshalJSON. Type names must be
+// registered with RegisterTypeName. The error of a decoded ERROR or WARNING
+// item is recreated from its value.
+func (i *Item) UnmarshalJSON(data []byte) error {
+	item, err := TypeNames(nil).unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*i = item
+	return nil
+}
+
+// unmarshal is UnmarshalJSON with the type names in n.
+func (n TypeNames) unmarshal(data []byte) (Item, error) {
+	var j jsonItem
+	if err := json.Unmarshal(data, &j); err != nil {
+		return Item{}, err
+	}
+	var typ ItemType
+	var name string
+	if err := json.Unmarshal(j.Typ, &name); err == nil {
+		t, ok := n.Type(name)
+		if !ok {
+			return Item{}, fmt.Errorf("scan: unknown item type %q", name)
+		}
+		typ = t
+	} else if err := json.Unmarshal(j.Typ, &typ); err != nil {
+		return Item{}, fmt.Errorf("scan: invalid item type %s", j.Typ)
+	}
+	item := Item{Typ: typ, Pos: j.Pos, Val: j.Val}
+	if j.End != nil {
+		item = item.withEnd(*j.End)
+	}
+	if typ == ERROR || typ == WARNING {
+		item.err = errors.New(j.Val)
+	}
+	return item, nil
+}
+
+// WriteJSON writes items to w as a JSON array with one item per line,
+// a format suited for tools like jq as well as for golden files.
+func WriteJSON(w io.Writer, items []Item) error {
+	return TypeNames(nil).WriteJSON(w, items)
+}
+
+// WriteJSON is like the function WriteJSON but encodes the type names in n.
+func (n TypeNames) WriteJSON(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[")
+	for k, item := range items {
+		data, err := n.marshal(item)
+		if err != nil {
+			return err
+		}
+		if k > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+		bw.Write(data)
+	}
+	bw.WriteString("\n]\n")
+	return bw.Flush()
+}
+
+// ReadJSON reads items written by WriteJSON, or any JSON array of items,
+// from r.
+func ReadJSON(r io.Reader) ([]Item, error) {
+	return TypeNames(nil).ReadJSON(r)
+}
+
+// ReadJSON is like the function ReadJSON but also accepts the type names
+// in n.
+func (n TypeNames) ReadJSON(r io.Reader) ([]Item, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	items := make([]Item, len(raw))
+	for k, data := range raw {
+		item, err := n.unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		items[k] = item
+	}
+	return items, nil
+}
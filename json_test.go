@@ -0,0 +1,84 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterTypeName(IDENTIFIER, "IDENTIFIER")
+	RegisterTypeName(INTEGER, "INTEGER")
+}
+
+func TestJSON(t *testing.T) {
+	items := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: PLUS, Pos: 2, Val: "+"},
+		{Typ: INTEGER, Pos: 4, Val: "12"},
+		{Typ: ERROR, Pos: 7, Val: "lex error"},
+		{Typ: EOF, Pos: 8},
+	}
+	var b strings.Builder
+	if err := WriteJSON(&b, items); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[
+{"type":"IDENTIFIER","pos":0,"val":"a"},
+{"type":4,"pos":2,"val":"+"},
+{"type":"INTEGER","pos":4,"val":"12"},
+{"type":"ERROR","pos":7,"val":"lex error"},
+{"type":"EOF","pos":8,"val":""}
+]
+`
+	if b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+	decoded, err := ReadJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(decoded, items, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", decoded, items)
+	}
+	if decoded[3].Err() == nil || decoded[3].Err().Error() != "lex error" {
+		t.Errorf("got error %v for the decoded error item", decoded[3].Err())
+	}
+	if _, err := ReadJSON(strings.NewReader(`[{"type":"NOSUCHTYPE"}]`)); err == nil {
+		t.Errorf("decoded an item with an unknown type name")
+	}
+}
+
+func TestJSONEnd(t *testing.T) {
+	s := New("json", `"x"`, func(s *Scanner) StateFn {
+		for s.Next() != EOF {
+		}
+		s.EmitTrimmed(IDENTIFIER, `"`)
+		return nil
+	})
+	items := drain(s)
+	var b strings.Builder
+	if err := WriteJSON(&b, items); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[
+{"type":"IDENTIFIER","pos":0,"val":"x","end":3},
+{"type":"EOF","pos":3,"val":""}
+]
+`
+	if b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+	decoded, err := ReadJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range items {
+		if decoded[k] != items[k] {
+			t.Errorf("got\n\t%+v\nexpected\n\t%+v", decoded[k], items[k])
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A KeywordSet maps keywords to item types. A lexer typically scans an
+// identifier and then looks it up:
+//
+//	if t, ok := keywords.Lookup(s.Text()); ok {
+//		s.Emit(t)
+//	} else {
+//		s.Emit(IDENTIFIER)
+//	}
+type KeywordSet struct {
+	words    map[string]ItemType
+	maxLen   int  // length in bytes of the longest keyword
+	fold     bool // keywords are matched ignoring case; words holds folded keys
+	maxRunes int  // length in runes of the longest keyword, if fold is set
+}
+
+// NewKeywordSet returns a keyword set containing the keywords in m.
+func NewKeywordSet(m map[string]ItemType) *KeywordSet {
+	k := &KeywordSet{words: make(map[string]ItemType, len(m))}
+	for word, t := range m {
+		k.words[word] = t
+		if len(word) > k.maxLen {
+			k.maxLen = len(word)
+		}
+	}
+	return k
+}
+
+// NewKeywordSetFold is like NewKeywordSet but returns a set whose Lookup
+// and Match methods ignore case under Unicode case folding, for languages
+// such as SQL or Pascal with case-insensitive keywords.
+func NewKeywordSetFold(m map[string]ItemType) *KeywordSet {
+	k := &KeywordSet{words: make(map[string]ItemType, len(m)), fold: true}
+	for word, t := range m {
+		k.words[foldString(word)] = t
+		k.maxRunes = max(k.maxRunes, utf8.RuneCountInString(word))
+	}
+	return k
+}
+
+// Lookup returns the item type of word and whether it is a keyword.
+func (k *KeywordSet) Lookup(word string) (ItemType, bool) {
+	if k.fold {
+		word = foldString(word)
+	}
+	t, ok := k.words[word]
+	return t, ok
+}
+
+// Match consumes the longest keyword at the current position of s and
+// returns its item type. If no keyword matches, nothing is consumed and
+// Match reports false. Match does not check what follows the keyword, so
+// "int" matches at the start of "integer" unless "integer" is a keyword too.
+func (k *KeywordSet) Match(s *Scanner) (ItemType, bool) {
+	if k.fold {
+		return k.matchFold(s)
+	}
+	rest := s.input[s.pos:]
+	n := k.maxLen
+	if n > len(rest) {
+		n = len(rest)
+	}
+	for ; n > 0; n-- {
+		if t, ok := k.words[rest[:n]]; ok {
+			s.pos += Pos(n)
+			s.width = 0
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// matchFold implements Match for a set created with NewKeywordSetFold.
+func (k *KeywordSet) matchFold(s *Scanner) (ItemType, bool) {
+	// Fold the input rune by rune, recording the end of each prefix.
+	var folded strings.Builder
+	var prefixes []int // length of folded at the end of each rune
+	var ends []Pos     // input offset at the end of each rune
+	for p := s.pos; len(ends) < k.maxRunes && int(p) < len(s.input); {
+		r, w := utf8.DecodeRuneInString(s.input[p:])
+		folded.WriteRune(foldRune(r))
+		p += Pos(w)
+		prefixes = append(prefixes, folded.Len())
+		ends = append(ends, p)
+	}
+	f := folded.String()
+	for n := len(ends) - 1; n >= 0; n-- {
+		if t, ok := k.words[f[:prefixes[n]]]; ok {
+			s.pos = ends[n]
+			s.width = 0
+			return t, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,87 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+const (
+	IN = iota + 100
+	INT
+	INTERFACE
+)
+
+var keywords = NewKeywordSet(map[string]ItemType{
+	"in":        IN,
+	"int":       INT,
+	"interface": INTERFACE,
+})
+
+func TestKeywordLookup(t *testing.T) {
+	if typ, ok := keywords.Lookup("int"); !ok || typ != INT {
+		t.Errorf("got %v, %v for int, expected %v, true", typ, ok, ItemType(INT))
+	}
+	if _, ok := keywords.Lookup("inter"); ok {
+		t.Errorf("inter is not a keyword")
+	}
+}
+
+func TestKeywordMatch(t *testing.T) {
+	tests := []struct {
+		input string
+		typ   ItemType
+		ok    bool
+		rest  string
+	}{
+		{"interface{}", INTERFACE, true, "{}"},
+		{"integer", INT, true, "eger"},
+		{"in x", IN, true, " x"},
+		{"i", 0, false, "i"},
+		{"", 0, false, ""},
+	}
+	for _, test := range tests {
+		s := New(test.input, test.input, nil)
+		typ, ok := keywords.Match(s)
+		if typ != test.typ || ok != test.ok {
+			t.Errorf("%q: got %v, %v, expected %v, %v", test.input, typ, ok, test.typ, test.ok)
+		}
+		if rest := s.input[s.pos:]; rest != test.rest {
+			t.Errorf("%q: got rest %q, expected %q", test.input, rest, test.rest)
+		}
+	}
+}
+
+var foldKeywords = NewKeywordSetFold(map[string]ItemType{
+	"in":        IN,
+	"int":       INT,
+	"interface": INTERFACE,
+})
+
+func TestKeywordFold(t *testing.T) {
+	if typ, ok := foldKeywords.Lookup("INT"); !ok || typ != INT {
+		t.Errorf("got %v, %v for INT, expected %v, true", typ, ok, ItemType(INT))
+	}
+	tests := []struct {
+		input string
+		typ   ItemType
+		ok    bool
+		rest  string
+	}{
+		{"InterFace{}", INTERFACE, true, "{}"},
+		{"INTEGER", INT, true, "EGER"},
+		{"In x", IN, true, " x"},
+		{"I", 0, false, "I"},
+		{"", 0, false, ""},
+	}
+	for _, test := range tests {
+		s := New(test.input, test.input, nil)
+		typ, ok := foldKeywords.Match(s)
+		if typ != test.typ || ok != test.ok {
+			t.Errorf("%q: got %v, %v, expected %v, %v", test.input, typ, ok, test.typ, test.ok)
+		}
+		if rest := s.input[s.pos:]; rest != test.rest {
+			t.Errorf("%q: got rest %q, expected %q", test.input, rest, test.rest)
+		}
+	}
+}
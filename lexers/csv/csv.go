@@ -0,0 +1,127 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package csv is a lexer for comma-separated values as specified by
+// RFC 4180, written with package scan. It shows how to use the scanner
+// for record-oriented input: a client reads the fields of a record until
+// an EndRecord item and can process each record as soon as it has been
+// scanned.
+//
+// Fields are separated by a configurable delimiter and records by "\r\n"
+// or "\n". A field may be enclosed in double quotes, in which case it may
+// contain delimiters, line breaks and doubled double quotes standing for
+// one double quote. An empty line is a record with one empty field.
+package csv
+
+import (
+	"strings"
+
+	"github.com/schulze/scan"
+)
+
+// Item types of CSV tokens.
+const (
+	Field       scan.ItemType = iota // unquoted field
+	QuotedField                      // quoted field, including the quotes; see Unquote
+	EndRecord                        // end of a record: the line break, or empty at the end of the input
+)
+
+// Names holds the names of the item types, for use with
+// scan.RegisterTypeName.
+var Names = map[scan.ItemType]string{
+	Field:       "Field",
+	QuotedField: "QuotedField",
+	EndRecord:   "EndRecord",
+}
+
+// New returns a scanner for the CSV text input with fields separated by
+// delim, which must not be a double quote, '\r' or '\n'.
+func New(name, input string, delim rune, opts ...scan.Option) *scan.Scanner {
+	return scan.New(name, input, Lex(delim), opts...)
+}
+
+// Lex returns the initial state of a lexer for CSV text with fields
+// separated by delim.
+func Lex(delim rune) scan.StateFn {
+	if delim == '"' || delim == '\r' || delim == '\n' {
+		panic("csv: invalid delimiter")
+	}
+	l := &lexer{delim: delim}
+	return l.lexRecord
+}
+
+// Unquote returns the value of a quoted field.
+func Unquote(field string) string {
+	field = strings.TrimSuffix(strings.TrimPrefix(field, `"`), `"`)
+	return strings.ReplaceAll(field, `""`, `"`)
+}
+
+type lexer struct {
+	delim rune
+}
+
+// lexRecord scans the start of a record.
+func (l *lexer) lexRecord(s *scan.Scanner) scan.StateFn {
+	if s.Peek() == scan.EOF {
+		s.Emit(scan.EOF)
+		return nil
+	}
+	return l.lexField
+}
+
+// lexField scans a field.
+func (l *lexer) lexField(s *scan.Scanner) scan.StateFn {
+	if s.Peek() == '"' {
+		return l.lexQuoted
+	}
+	for {
+		switch r := s.Next(); {
+		case r == l.delim || r == '\n' || r == scan.EOF:
+			s.Backup()
+			s.Emit(Field)
+			return l.lexAfterField
+		case r == '\r' && s.Peek() == '\n':
+			s.Backup()
+			s.Emit(Field)
+			return l.lexAfterField
+		case r == '"':
+			return s.Errorf("bare \" in unquoted field")
+		}
+	}
+}
+
+// lexQuoted scans a quoted field.
+func (l *lexer) lexQuoted(s *scan.Scanner) scan.StateFn {
+	s.Next() // the opening quote
+	for {
+		switch s.Next() {
+		case '"':
+			if s.Peek() != '"' {
+				s.Emit(QuotedField)
+				return l.lexAfterField
+			}
+			s.Next()
+		case scan.EOF:
+			return s.Errorf("unterminated quoted field")
+		}
+	}
+}
+
+// lexAfterField scans the delimiter or line break after a field.
+func (l *lexer) lexAfterField(s *scan.Scanner) scan.StateFn {
+	switch r := s.Next(); {
+	case r == l.delim:
+		s.Ignore()
+		return l.lexField
+	case r == '\n' || r == '\r' && s.Accept("\n"):
+		s.Emit(EndRecord)
+		return l.lexRecord
+	case r == scan.EOF:
+		s.Emit(EndRecord)
+		s.Emit(scan.EOF)
+		return nil
+	default:
+		return s.Errorf("unexpected %q after quoted field", r)
+	}
+}
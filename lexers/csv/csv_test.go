@@ -0,0 +1,95 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"testing"
+
+	"github.com/schulze/scan"
+	"github.com/schulze/scan/fuzztest"
+	"github.com/schulze/scan/scantest"
+)
+
+func init() {
+	for t, name := range Names {
+		scan.RegisterTypeName(t, name)
+	}
+}
+
+type lexTest struct {
+	name  string
+	input string
+	delim rune
+	items []scan.Item
+}
+
+var lexTests = []lexTest{
+	{"empty", "", ',', []scan.Item{
+		{Typ: scan.EOF, Pos: 0},
+	}},
+	{"records", "a,b\r\nc,\n", ',', []scan.Item{
+		{Typ: Field, Pos: 0, Val: "a"},
+		{Typ: Field, Pos: 2, Val: "b"},
+		{Typ: EndRecord, Pos: 3, Val: "\r\n"},
+		{Typ: Field, Pos: 5, Val: "c"},
+		{Typ: Field, Pos: 7, Val: ""},
+		{Typ: EndRecord, Pos: 7, Val: "\n"},
+		{Typ: scan.EOF, Pos: 8},
+	}},
+	{"no final line break", "a\tb c", '\t', []scan.Item{
+		{Typ: Field, Pos: 0, Val: "a"},
+		{Typ: Field, Pos: 2, Val: "b c"},
+		{Typ: EndRecord, Pos: 5, Val: ""},
+		{Typ: scan.EOF, Pos: 5},
+	}},
+	{"quoted", "\"a,\"\"b\"\"\r\n\",x\r", ',', []scan.Item{
+		{Typ: QuotedField, Pos: 0, Val: "\"a,\"\"b\"\"\r\n\""},
+		{Typ: Field, Pos: 12, Val: "x\r"},
+		{Typ: EndRecord, Pos: 14, Val: ""},
+		{Typ: scan.EOF, Pos: 14},
+	}},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		t.Run(test.name, func(t *testing.T) {
+			scantest.RequireItems(t, scantest.Collect(New(test.name, test.input, test.delim)), test.items, 0)
+		})
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	if got := Unquote("\"a,\"\"b\"\"\r\n\""); got != "a,\"b\"\r\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+type errorTest struct {
+	input string
+	err   string
+}
+
+var errorTests = []errorTest{
+	{`a"b`, `bare " in unquoted field`},
+	{`"ab`, "unterminated quoted field"},
+	{`"a"b`, `unexpected 'b' after quoted field`},
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range errorTests {
+		items := scantest.Collect(New(test.input, test.input, ','))
+		if got := items[len(items)-2]; got.Typ != scan.ERROR || got.Val != test.err {
+			t.Errorf("%q: got %v, expected error %q", test.input, got, test.err)
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	var seeds []string
+	for _, test := range lexTests {
+		seeds = append(seeds, test.input)
+	}
+	fuzztest.Config{Start: Lex(',')}.Fuzz(f, seeds...)
+}
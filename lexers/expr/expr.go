@@ -0,0 +1,137 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package expr is a lexer for arithmetic expressions, written with package
+// scan. Next to the tokens it exports the precedence and associativity of
+// the operators, which is all an operator-precedence parser needs:
+//
+//	1 + 2 * -x ^ 2 ^ 3  (* parsed as 1 + (2 * -(x ^ (2 ^ 3))) *)
+//
+// Expressions consist of numbers, identifiers, parentheses and the
+// operators + - * / % ^. White space is ignored, and comments are enclosed
+// in "(*" and "*)" and may nest, so "(*" always starts a comment.
+package expr
+
+import (
+	"unicode"
+
+	"github.com/schulze/scan"
+)
+
+// Item types of expression tokens.
+const (
+	Number     scan.ItemType = iota // number, such as 42, 0x2a or 4.2e1
+	Ident                           // identifier
+	LeftParen                       // '('
+	RightParen                      // ')'
+	Plus                            // '+'
+	Minus                           // '-'
+	Star                            // '*'
+	Slash                           // '/'
+	Percent                         // '%'
+	Caret                           // '^', exponentiation
+)
+
+// Names holds the names of the item types, for use with
+// scan.RegisterTypeName.
+var Names = map[scan.ItemType]string{
+	Number:     "Number",
+	Ident:      "Ident",
+	LeftParen:  "LeftParen",
+	RightParen: "RightParen",
+	Plus:       "Plus",
+	Minus:      "Minus",
+	Star:       "Star",
+	Slash:      "Slash",
+	Percent:    "Percent",
+	Caret:      "Caret",
+}
+
+// Assoc is the associativity of an operator.
+type Assoc int
+
+const (
+	LeftAssoc  Assoc = iota // a op b op c is (a op b) op c
+	RightAssoc              // a op b op c is a op (b op c)
+)
+
+// An Op describes an operator. Operators with a higher precedence bind
+// more tightly.
+type Op struct {
+	Prec  int
+	Assoc Assoc
+}
+
+// BinaryOps holds the infix operators. Exponentiation binds more tightly
+// than the multiplicative operators, which bind more tightly than the
+// additive ones.
+var BinaryOps = map[scan.ItemType]Op{
+	Plus:    {1, LeftAssoc},
+	Minus:   {1, LeftAssoc},
+	Star:    {2, LeftAssoc},
+	Slash:   {2, LeftAssoc},
+	Percent: {2, LeftAssoc},
+	Caret:   {4, RightAssoc},
+}
+
+// UnaryOps holds the prefix operators. They bind more tightly than the
+// multiplicative operators but less tightly than exponentiation, so -x^2
+// is -(x^2).
+var UnaryOps = map[scan.ItemType]Op{
+	Plus:  {3, RightAssoc},
+	Minus: {3, RightAssoc},
+}
+
+// New returns a scanner for the expression input.
+func New(name, input string, opts ...scan.Option) *scan.Scanner {
+	return scan.New(name, input, Lex, opts...)
+}
+
+var operators = scan.NewOperatorTable(map[string]scan.ItemType{
+	"(": LeftParen,
+	")": RightParen,
+	"+": Plus,
+	"-": Minus,
+	"*": Star,
+	"/": Slash,
+	"%": Percent,
+	"^": Caret,
+})
+
+var numberTypes = scan.NumberTypes{
+	Int:    Number,
+	Hex:    Number,
+	Octal:  Number,
+	Binary: Number,
+	Float:  Number,
+}
+
+// lexNumber scans a number.
+func lexNumber(s *scan.Scanner) scan.StateFn {
+	return scan.LexNumber(numberTypes, Lex)(s)
+}
+
+// Lex is the initial state of the expression lexer. It scans any token.
+func Lex(s *scan.Scanner) scan.StateFn {
+	for s.SkipSpace(scan.AllSpace) || s.SkipBlockComment("(*", "*)", true) {
+	}
+	switch r := s.Peek(); {
+	case r == scan.EOF:
+		s.Emit(scan.EOF)
+		return nil
+	case '0' <= r && r <= '9' || r == '.':
+		return lexNumber
+	case r == '_' || unicode.IsLetter(r):
+		for r := s.Peek(); r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r); r = s.Peek() {
+			s.Next()
+		}
+		s.Emit(Ident)
+		return Lex
+	}
+	if t, ok := operators.Match(s); ok {
+		s.Emit(t)
+		return Lex
+	}
+	return s.Errorf("unexpected character %q", s.Next())
+}
@@ -0,0 +1,211 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/schulze/scan"
+	"github.com/schulze/scan/fuzztest"
+	"github.com/schulze/scan/scantest"
+)
+
+func init() {
+	for t, name := range Names {
+		scan.RegisterTypeName(t, name)
+	}
+}
+
+type lexTest struct {
+	name  string
+	input string
+	items []scan.Item
+}
+
+var lexTests = []lexTest{
+	{"empty", "", []scan.Item{
+		{Typ: scan.EOF, Pos: 0},
+	}},
+	{"operators", "a1+0x2a*(.5-b)/c%d^2", []scan.Item{
+		{Typ: Ident, Pos: 0, Val: "a1"},
+		{Typ: Plus, Pos: 2, Val: "+"},
+		{Typ: Number, Pos: 3, Val: "0x2a"},
+		{Typ: Star, Pos: 7, Val: "*"},
+		{Typ: LeftParen, Pos: 8, Val: "("},
+		{Typ: Number, Pos: 9, Val: ".5"},
+		{Typ: Minus, Pos: 11, Val: "-"},
+		{Typ: Ident, Pos: 12, Val: "b"},
+		{Typ: RightParen, Pos: 13, Val: ")"},
+		{Typ: Slash, Pos: 14, Val: "/"},
+		{Typ: Ident, Pos: 15, Val: "c"},
+		{Typ: Percent, Pos: 16, Val: "%"},
+		{Typ: Ident, Pos: 17, Val: "d"},
+		{Typ: Caret, Pos: 18, Val: "^"},
+		{Typ: Number, Pos: 19, Val: "2"},
+		{Typ: scan.EOF, Pos: 20},
+	}},
+	{"comments", " 1 (* a (* b *) *)\n+ 2 ", []scan.Item{
+		{Typ: Number, Pos: 1, Val: "1"},
+		{Typ: Plus, Pos: 19, Val: "+"},
+		{Typ: Number, Pos: 21, Val: "2"},
+		{Typ: scan.EOF, Pos: 23},
+	}},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		t.Run(test.name, func(t *testing.T) {
+			scantest.RequireItems(t, scantest.Collect(New(test.name, test.input)), test.items, 0)
+		})
+	}
+}
+
+type errorTest struct {
+	input string
+	err   string
+}
+
+var errorTests = []errorTest{
+	{"1 # 2", `unexpected character '#'`},
+	{"1e", `bad number syntax: "1e"`},
+	{"1 (* 2", "unterminated comment starting at 1:3"},
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range errorTests {
+		errs := New(test.input, test.input)
+		scantest.Collect(errs)
+		if got := errs.Errors(); len(got) != 1 || got[0].Val != test.err {
+			t.Errorf("%q: got %v, expected error %q", test.input, got, test.err)
+		}
+	}
+}
+
+// parser is an operator-precedence parser for the tokens of the lexer,
+// driven by BinaryOps and UnaryOps. It formats the parsed expression with
+// explicit parentheses.
+type parser struct {
+	s *scan.Scanner
+}
+
+// parse parses an expression whose binary operators have a precedence of
+// at least prec.
+func (p *parser) parse(prec int) (string, error) {
+	x, err := p.operand()
+	if err != nil {
+		return "", err
+	}
+	for {
+		op, ok := BinaryOps[p.s.PeekItem().Typ]
+		if !ok || op.Prec < prec {
+			return x, nil
+		}
+		tok := p.s.NextItem()
+		next := op.Prec + 1
+		if op.Assoc == RightAssoc {
+			next = op.Prec
+		}
+		y, err := p.parse(next)
+		if err != nil {
+			return "", err
+		}
+		x = fmt.Sprintf("(%s %s %s)", x, tok.Val, y)
+	}
+}
+
+func (p *parser) operand() (string, error) {
+	tok := p.s.NextItem()
+	if op, ok := UnaryOps[tok.Typ]; ok {
+		x, err := p.parse(op.Prec)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s%s)", tok.Val, x), nil
+	}
+	switch tok.Typ {
+	case Number, Ident:
+		return tok.Val, nil
+	case LeftParen:
+		x, err := p.parse(0)
+		if err != nil {
+			return "", err
+		}
+		if tok := p.s.NextItem(); tok.Typ != RightParen {
+			return "", fmt.Errorf("expected ')', found %v", tok)
+		}
+		return x, nil
+	}
+	return "", fmt.Errorf("unexpected %v", tok)
+}
+
+func parse(input string) (string, error) {
+	p := &parser{s: New("parse", input)}
+	x, err := p.parse(0)
+	for tok := p.s.NextItem(); tok.Typ != scan.EOF; tok = p.s.NextItem() {
+		if err == nil {
+			err = fmt.Errorf("unexpected %v", tok)
+		}
+	}
+	return x, err
+}
+
+var parseTests = []struct {
+	input, want string
+}{
+	{"1 + 2 * -x ^ 2 ^ 3", "(1 + (2 * (-(x ^ (2 ^ 3)))))"},
+	{"a - b - c", "((a - b) - c)"},
+	{"a / b % c", "((a / b) % c)"},
+	{"-a * b", "((-a) * b)"},
+	{"(a + b) * c", "((a + b) * c)"},
+	{"2 ^ -1", "(2 ^ (-1))"},
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range parseTests {
+		got, err := parse(test.input)
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("%q: got\n\t%s\nexpected\n\t%s", test.input, got, test.want)
+		}
+	}
+}
+
+func benchmarkInput() string {
+	return strings.Repeat("(x1 + 0x2a * -y ^ 2) % 3.5e1 - z / 7 + ", 1000) + "1"
+}
+
+func BenchmarkLex(b *testing.B) {
+	input := benchmarkInput()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		s := New("bench", input)
+		for s.NextItem().Typ != scan.EOF {
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	input := benchmarkInput()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		if _, err := parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	seeds := []string{}
+	for _, test := range lexTests {
+		seeds = append(seeds, test.input)
+	}
+	for _, test := range errorTests {
+		seeds = append(seeds, test.input)
+	}
+	fuzztest.Config{Start: Lex}.Fuzz(f, seeds...)
+}
@@ -0,0 +1,190 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ini is a lexer for INI-style configuration files, written with
+// package scan. It accepts the common subset of INI and TOML: section
+// headers, key-value pairs and comments, one per line.
+//
+//	# comment
+//	[section.sub]
+//	key = bare value
+//	"quoted key": "quoted\tvalue" ; comment
+//
+// A key is a run of letters, digits, '_', '-' and '.', or a quoted string,
+// and is separated from its value by '=' or ':'. A bare value extends to
+// the end of the line, without surrounding blanks; a quoted value may be
+// followed by a comment. Comments start with '#' or ';'. The lexer is line
+// oriented: every line break is emitted as a Newline item, so a client can
+// process the file entry by entry. The first malformed line ends the scan
+// with an error item.
+package ini
+
+import (
+	"unicode"
+
+	"github.com/schulze/scan"
+)
+
+// Item types of configuration tokens.
+const (
+	Section scan.ItemType = iota // section header, including the brackets
+	Key                          // bare key
+	Assign                       // '=' or ':'
+	Value                        // bare value
+	String                       // quoted key or value, including quotes; escapes are not interpreted
+	Comment                      // comment, including the '#' or ';'
+	Newline                      // line break
+)
+
+// Names holds the names of the item types, for use with
+// scan.RegisterTypeName.
+var Names = map[scan.ItemType]string{
+	Section: "Section",
+	Key:     "Key",
+	Assign:  "Assign",
+	Value:   "Value",
+	String:  "String",
+	Comment: "Comment",
+	Newline: "Newline",
+}
+
+// New returns a scanner for the configuration text input.
+func New(name, input string, opts ...scan.Option) *scan.Scanner {
+	return scan.New(name, input, Lex, opts...)
+}
+
+var keyChars = scan.NewRuneSet("_-.").AddFunc(unicode.IsLetter).AddFunc(unicode.IsDigit)
+
+// Lex is the initial state of the configuration lexer. It scans a line.
+func Lex(s *scan.Scanner) scan.StateFn {
+	s.SkipSpace(scan.Blanks)
+	switch r := s.Peek(); {
+	case r == scan.EOF:
+		s.Emit(scan.EOF)
+		return nil
+	case r == '\n' || r == '\r':
+		return lexEndOfLine
+	case r == '#' || r == ';':
+		return lexComment
+	case r == '[':
+		return lexSection
+	case r == '"':
+		if !lexString(s) {
+			return nil
+		}
+		return lexAssign
+	case keyChars.Contains(r):
+		s.AcceptRunSet(keyChars)
+		s.Emit(Key)
+		return lexAssign
+	default:
+		s.Next()
+		return s.Errorf("unexpected character %q at start of line", r)
+	}
+}
+
+// lexEndOfLine scans the line break at the end of a line, if any.
+func lexEndOfLine(s *scan.Scanner) scan.StateFn {
+	switch s.Next() {
+	case scan.EOF:
+		s.Backup()
+		return Lex
+	case '\n':
+	case '\r':
+		if !s.Accept("\n") {
+			return s.Errorf("carriage return without line feed")
+		}
+	default:
+		return s.Errorf("unexpected %q at end of line", s.Text())
+	}
+	s.Emit(Newline)
+	return Lex
+}
+
+// lexComment scans a comment, which ends the line.
+func lexComment(s *scan.Scanner) scan.StateFn {
+	for !isEndOfLine(s.Peek()) {
+		s.Next()
+	}
+	s.Emit(Comment)
+	return lexEndOfLine
+}
+
+// lexSection scans a section header.
+func lexSection(s *scan.Scanner) scan.StateFn {
+	s.Next() // '['
+	for r := s.Next(); r != ']'; r = s.Next() {
+		if isEndOfLine(r) {
+			s.Backup()
+			return s.Errorf("unterminated section header")
+		}
+	}
+	s.Emit(Section)
+	return lexTrailer
+}
+
+// lexAssign scans the separator between a key and its value.
+func lexAssign(s *scan.Scanner) scan.StateFn {
+	s.SkipSpace(scan.Blanks)
+	if !s.Accept("=:") {
+		return s.Errorf("missing '=' after key")
+	}
+	s.Emit(Assign)
+	return lexValue
+}
+
+// lexValue scans a value.
+func lexValue(s *scan.Scanner) scan.StateFn {
+	s.SkipSpace(scan.Blanks)
+	if s.Peek() == '"' {
+		if !lexString(s) {
+			return nil
+		}
+		return lexTrailer
+	}
+	end := s.Mark()
+	for r := s.Peek(); !isEndOfLine(r); r = s.Peek() {
+		s.Next()
+		if r != ' ' && r != '\t' {
+			end = s.Mark()
+		}
+	}
+	s.Rewind(end)
+	s.Emit(Value)
+	return lexTrailer
+}
+
+// lexTrailer scans the rest of a line after a section header or a quoted
+// value: blanks and an optional comment.
+func lexTrailer(s *scan.Scanner) scan.StateFn {
+	s.SkipSpace(scan.Blanks)
+	if r := s.Peek(); r == '#' || r == ';' {
+		return lexComment
+	}
+	return lexEndOfLine
+}
+
+// lexString scans a quoted string and reports whether it is terminated.
+func lexString(s *scan.Scanner) bool {
+	s.Next() // '"'
+	for {
+		switch r := s.Next(); {
+		case r == '"':
+			s.Emit(String)
+			return true
+		case r == '\\':
+			if !isEndOfLine(s.Peek()) {
+				s.Next()
+			}
+		case isEndOfLine(r):
+			s.Backup()
+			s.Errorf("unterminated string")
+			return false
+		}
+	}
+}
+
+func isEndOfLine(r rune) bool {
+	return r == '\n' || r == '\r' || r == scan.EOF
+}
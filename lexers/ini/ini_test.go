@@ -0,0 +1,106 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ini
+
+import (
+	"testing"
+
+	"github.com/schulze/scan"
+	"github.com/schulze/scan/fuzztest"
+	"github.com/schulze/scan/scantest"
+)
+
+func init() {
+	for t, name := range Names {
+		scan.RegisterTypeName(t, name)
+	}
+}
+
+type lexTest struct {
+	name  string
+	input string
+	items []scan.Item
+}
+
+var lexTests = []lexTest{
+	{"empty", "", []scan.Item{
+		{Typ: scan.EOF, Pos: 0},
+	}},
+	{"section", "[core] # c\r\n\n", []scan.Item{
+		{Typ: Section, Pos: 0, Val: "[core]"},
+		{Typ: Comment, Pos: 7, Val: "# c"},
+		{Typ: Newline, Pos: 10, Val: "\r\n"},
+		{Typ: Newline, Pos: 12, Val: "\n"},
+		{Typ: scan.EOF, Pos: 13},
+	}},
+	{"bare value", "  a.b = x # y \t\n", []scan.Item{
+		{Typ: Key, Pos: 2, Val: "a.b"},
+		{Typ: Assign, Pos: 6, Val: "="},
+		{Typ: Value, Pos: 8, Val: "x # y"},
+		{Typ: Newline, Pos: 15, Val: "\n"},
+		{Typ: scan.EOF, Pos: 16},
+	}},
+	{"quoted", `"k\"": "v" ;c`, []scan.Item{
+		{Typ: String, Pos: 0, Val: `"k\""`},
+		{Typ: Assign, Pos: 5, Val: ":"},
+		{Typ: String, Pos: 7, Val: `"v"`},
+		{Typ: Comment, Pos: 11, Val: ";c"},
+		{Typ: scan.EOF, Pos: 13},
+	}},
+	{"empty value", "k=\n", []scan.Item{
+		{Typ: Key, Pos: 0, Val: "k"},
+		{Typ: Assign, Pos: 1, Val: "="},
+		{Typ: Value, Pos: 2, Val: ""},
+		{Typ: Newline, Pos: 2, Val: "\n"},
+		{Typ: scan.EOF, Pos: 3},
+	}},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		t.Run(test.name, func(t *testing.T) {
+			scantest.RequireItems(t, scantest.Collect(New(test.name, test.input)), test.items, 0)
+		})
+	}
+}
+
+type errorTest struct {
+	input string
+	err   string
+}
+
+var errorTests = []errorTest{
+	{"[core\n", "unterminated section header"},
+	{"[core] x", `unexpected "x" at end of line`},
+	{"k v", "missing '=' after key"},
+	{`k = "v`, "unterminated string"},
+	{`k = "v" w`, `unexpected "w" at end of line`},
+	{"k = v\r", "carriage return without line feed"},
+	{"=v", `unexpected character '=' at start of line`},
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range errorTests {
+		items := scantest.Collect(New(test.input, test.input))
+		if len(items) < 2 {
+			t.Errorf("%q: got %v, expected an error", test.input, items)
+			continue
+		}
+		if got := items[len(items)-2]; got.Typ != scan.ERROR || got.Val != test.err {
+			t.Errorf("%q: got %v, expected error %q", test.input, got, test.err)
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	seeds := []string{}
+	for _, test := range lexTests {
+		seeds = append(seeds, test.input)
+	}
+	for _, test := range errorTests {
+		seeds = append(seeds, test.input)
+	}
+	fuzztest.Config{Start: Lex}.Fuzz(f, seeds...)
+}
@@ -0,0 +1,198 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package json is a lexer for JSON as specified by RFC 8259, written with
+// package scan. It is meant both for use and as an example of the
+// state-function style of lexers.
+//
+// The lexer checks the syntax of tokens but not their order, which is the
+// job of a parser. The first malformed token ends the scan with an error
+// item.
+package json
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/schulze/scan"
+)
+
+// Item types of JSON tokens.
+const (
+	LeftBrace    scan.ItemType = iota // '{'
+	RightBrace                        // '}'
+	LeftBracket                       // '['
+	RightBracket                      // ']'
+	Colon                             // ':'
+	Comma                             // ','
+	String                            // string, including quotes; escapes are not interpreted
+	Number                            // number
+	True                              // true
+	False                             // false
+	Null                              // null
+)
+
+// Names holds the names of the item types, for use with
+// scan.RegisterTypeName.
+var Names = map[scan.ItemType]string{
+	LeftBrace:    "LeftBrace",
+	RightBrace:   "RightBrace",
+	LeftBracket:  "LeftBracket",
+	RightBracket: "RightBracket",
+	Colon:        "Colon",
+	Comma:        "Comma",
+	String:       "String",
+	Number:       "Number",
+	True:         "True",
+	False:        "False",
+	Null:         "Null",
+}
+
+// New returns a scanner for the JSON text input.
+func New(name, input string, opts ...scan.Option) *scan.Scanner {
+	return scan.New(name, input, Lex, opts...)
+}
+
+// space is the white space allowed between JSON tokens.
+var space = scan.Whitespace{IsSpace: func(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}}
+
+var structural = map[rune]scan.ItemType{
+	'{': LeftBrace,
+	'}': RightBrace,
+	'[': LeftBracket,
+	']': RightBracket,
+	':': Colon,
+	',': Comma,
+}
+
+// Lex is the initial state of the JSON lexer. It scans any token.
+func Lex(s *scan.Scanner) scan.StateFn {
+	s.SkipSpace(space)
+	switch r := s.Peek(); {
+	case r == scan.EOF:
+		s.Emit(scan.EOF)
+		return nil
+	case r == '"':
+		return lexString
+	case r == '-' || '0' <= r && r <= '9':
+		return lexNumber
+	case 'a' <= r && r <= 'z':
+		return lexLiteral
+	default:
+		if t, ok := structural[r]; ok {
+			s.Next()
+			s.Emit(t)
+			return Lex
+		}
+		s.Next()
+		return s.Errorf("unexpected character %q", r)
+	}
+}
+
+// lexString scans a string.
+func lexString(s *scan.Scanner) scan.StateFn {
+	s.Next() // the opening quote
+	for {
+		switch r := s.Next(); {
+		case r == '"':
+			s.Emit(String)
+			return Lex
+		case r == '\\':
+			if !lexEscape(s) {
+				return nil
+			}
+		case r == scan.EOF:
+			return s.Errorf("unterminated string")
+		case r < 0x20:
+			return s.Errorf("control character %U in string", r)
+		case r == utf8.RuneError && !strings.HasSuffix(s.Text(), string(utf8.RuneError)):
+			return s.Errorf("invalid UTF-8 in string")
+		}
+	}
+}
+
+// lexEscape scans an escape sequence after the backslash and reports
+// whether it is valid.
+func lexEscape(s *scan.Scanner) bool {
+	switch r := s.Next(); r {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return true
+	case 'u':
+		for i := 0; i < 4; i++ {
+			if !s.Accept(hexDigits) {
+				s.Errorf("invalid \\u escape in string")
+				return false
+			}
+		}
+		return true
+	case scan.EOF:
+		s.Errorf("unterminated string")
+		return false
+	default:
+		s.Errorf("invalid escape \\%c in string", r)
+		return false
+	}
+}
+
+const (
+	digits    = "0123456789"
+	hexDigits = "0123456789abcdefABCDEF"
+)
+
+// lexNumber scans a number: an optional minus sign, an integer without
+// leading zeros, an optional fraction and an optional exponent.
+func lexNumber(s *scan.Scanner) scan.StateFn {
+	s.Accept("-")
+	switch {
+	case s.Accept("0"):
+	case s.Accept("123456789"):
+		s.AcceptRun(digits)
+	default:
+		return s.Errorf("missing digits in number %q", s.Text())
+	}
+	if s.Accept(".") {
+		if !s.Accept(digits) {
+			return s.Errorf("missing digits after decimal point in number %q", s.Text())
+		}
+		s.AcceptRun(digits)
+	}
+	if s.Accept("eE") {
+		s.Accept("+-")
+		if !s.Accept(digits) {
+			return s.Errorf("missing digits in exponent of number %q", s.Text())
+		}
+		s.AcceptRun(digits)
+	}
+	if r := s.Peek(); isWordRune(r) || r == '.' {
+		s.Next()
+		return s.Errorf("bad number syntax %q", s.Text())
+	}
+	s.Emit(Number)
+	return Lex
+}
+
+var literals = map[string]scan.ItemType{
+	"true":  True,
+	"false": False,
+	"null":  Null,
+}
+
+// lexLiteral scans true, false or null.
+func lexLiteral(s *scan.Scanner) scan.StateFn {
+	for isWordRune(s.Peek()) {
+		s.Next()
+	}
+	t, ok := literals[s.Text()]
+	if !ok {
+		return s.Errorf("invalid literal %q", s.Text())
+	}
+	s.Emit(t)
+	return Lex
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+}
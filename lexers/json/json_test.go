@@ -0,0 +1,123 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"testing"
+
+	"github.com/schulze/scan"
+	"github.com/schulze/scan/fuzztest"
+	"github.com/schulze/scan/scantest"
+)
+
+func init() {
+	for t, name := range Names {
+		scan.RegisterTypeName(t, name)
+	}
+}
+
+type lexTest struct {
+	name  string
+	input string
+	items []scan.Item
+}
+
+var lexTests = []lexTest{
+	{"empty", " \t\r\n", []scan.Item{
+		{Typ: scan.EOF, Pos: 4},
+	}},
+	{"document", `{"a": [1, -2.5e+3, true, false, null], "b\"é": {}}`, []scan.Item{
+		{Typ: LeftBrace, Pos: 0, Val: "{"},
+		{Typ: String, Pos: 1, Val: `"a"`},
+		{Typ: Colon, Pos: 4, Val: ":"},
+		{Typ: LeftBracket, Pos: 6, Val: "["},
+		{Typ: Number, Pos: 7, Val: "1"},
+		{Typ: Comma, Pos: 8, Val: ","},
+		{Typ: Number, Pos: 10, Val: "-2.5e+3"},
+		{Typ: Comma, Pos: 17, Val: ","},
+		{Typ: True, Pos: 19, Val: "true"},
+		{Typ: Comma, Pos: 23, Val: ","},
+		{Typ: False, Pos: 25, Val: "false"},
+		{Typ: Comma, Pos: 30, Val: ","},
+		{Typ: Null, Pos: 32, Val: "null"},
+		{Typ: RightBracket, Pos: 36, Val: "]"},
+		{Typ: Comma, Pos: 37, Val: ","},
+		{Typ: String, Pos: 39, Val: `"b\"é"`},
+		{Typ: Colon, Pos: 46, Val: ":"},
+		{Typ: LeftBrace, Pos: 48, Val: "{"},
+		{Typ: RightBrace, Pos: 49, Val: "}"},
+		{Typ: RightBrace, Pos: 50, Val: "}"},
+		{Typ: scan.EOF, Pos: 51},
+	}},
+	{"numbers", "0 -0 0.5 1E9 12e-3", []scan.Item{
+		{Typ: Number, Pos: 0, Val: "0"},
+		{Typ: Number, Pos: 2, Val: "-0"},
+		{Typ: Number, Pos: 5, Val: "0.5"},
+		{Typ: Number, Pos: 9, Val: "1E9"},
+		{Typ: Number, Pos: 13, Val: "12e-3"},
+		{Typ: scan.EOF, Pos: 18},
+	}},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		t.Run(test.name, func(t *testing.T) {
+			scantest.RequireItems(t, scantest.Collect(New(test.name, test.input)), test.items, 0)
+		})
+	}
+}
+
+type errorTest struct {
+	input string
+	err   string
+}
+
+var errorTests = []errorTest{
+	{`"abc`, "unterminated string"},
+	{`"a\qb"`, `invalid escape \q in string`},
+	{`"\u12g4"`, `invalid \u escape in string`},
+	{"\"a\tb\"", "control character U+0009 in string"},
+	{"\"a\xffb\"", "invalid UTF-8 in string"},
+	{"01", `bad number syntax "01"`},
+	{"-", `missing digits in number "-"`},
+	{"1.", `missing digits after decimal point in number "1."`},
+	{"1e+", `missing digits in exponent of number "1e+"`},
+	{"1x", `bad number syntax "1x"`},
+	{"nul", `invalid literal "nul"`},
+	{"True", `unexpected character 'T'`},
+	{"'a'", `unexpected character '\''`},
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range errorTests {
+		items := scantest.Collect(New(test.input, test.input))
+		if len(items) < 2 {
+			t.Errorf("%q: got %v, expected an error", test.input, items)
+			continue
+		}
+		if got := items[len(items)-2]; got.Typ != scan.ERROR || got.Val != test.err {
+			t.Errorf("%q: got %v, expected error %q", test.input, got, test.err)
+		}
+	}
+}
+
+func TestValidUTF8(t *testing.T) {
+	input := "\"\uFFFDé\""
+	scantest.RequireItems(t, scantest.Collect(New("utf8", input)), []scan.Item{
+		{Typ: String, Pos: 0, Val: input},
+		{Typ: scan.EOF, Pos: scan.Pos(len(input))},
+	}, 0)
+}
+
+func FuzzLex(f *testing.F) {
+	seeds := []string{}
+	for _, test := range lexTests {
+		seeds = append(seeds, test.input)
+	}
+	for _, test := range errorTests {
+		seeds = append(seeds, test.input)
+	}
+	fuzztest.Config{Start: Lex}.Fuzz(f, seeds...)
+}
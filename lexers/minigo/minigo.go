@@ -0,0 +1,205 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package minigo is a lexer for the tokens of Go, written with package
+// scan. It demonstrates automatic semicolon insertion: like the Go
+// scanner, it turns a line break into a semicolon when the line ends with
+// a token that may end a statement, so that a parser can require
+// semicolons between statements while programs rarely contain any.
+//
+// The pattern needs no support from the parser. Where the lexer reaches a
+// line break or the end of the input, it consults the last emitted item,
+// as returned by the scanner's LastItem method, and emits a Semicolon item
+// if that item may end a statement:
+//
+//	case r == '\n':
+//		s.Next()
+//		if last, ok := s.LastItem(); ok && endsStatement(last) {
+//			s.Emit(Semicolon) // its value is "\n"
+//		} else {
+//			s.Ignore()
+//		}
+//
+// An inserted semicolon thus has the line break as its value, or is empty
+// at the end of the input, and can be told apart from an explicit ";".
+// Comments are skipped; a line comment leaves the line break after it to
+// trigger the insertion, while a general comment containing line breaks
+// acts like one and may produce an empty semicolon.
+package minigo
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/schulze/scan"
+)
+
+// Item types of Go tokens.
+const (
+	Ident     scan.ItemType = iota // identifier
+	Keyword                        // keyword
+	Int                            // integer literal
+	Float                          // floating-point literal
+	Char                           // rune literal, including quotes
+	String                         // interpreted or raw string literal, including quotes
+	Operator                       // operator or punctuation other than ';'
+	Semicolon                      // ';', or inserted at a line break or the end of the input
+)
+
+// Names holds the names of the item types, for use with
+// scan.RegisterTypeName.
+var Names = map[scan.ItemType]string{
+	Ident:     "Ident",
+	Keyword:   "Keyword",
+	Int:       "Int",
+	Float:     "Float",
+	Char:      "Char",
+	String:    "String",
+	Operator:  "Operator",
+	Semicolon: "Semicolon",
+}
+
+// New returns a scanner for the Go source text input.
+func New(name, input string, opts ...scan.Option) *scan.Scanner {
+	return scan.New(name, input, Lex, opts...)
+}
+
+var keywords = scan.NewKeywordSet(map[string]scan.ItemType{
+	"break": Keyword, "case": Keyword, "chan": Keyword, "const": Keyword,
+	"continue": Keyword, "default": Keyword, "defer": Keyword, "else": Keyword,
+	"fallthrough": Keyword, "for": Keyword, "func": Keyword, "go": Keyword,
+	"goto": Keyword, "if": Keyword, "import": Keyword, "interface": Keyword,
+	"map": Keyword, "package": Keyword, "range": Keyword, "return": Keyword,
+	"select": Keyword, "struct": Keyword, "switch": Keyword, "type": Keyword,
+	"var": Keyword,
+})
+
+var operators = scan.NewOperatorTable(map[string]scan.ItemType{
+	"+": Operator, "-": Operator, "*": Operator, "/": Operator, "%": Operator,
+	"&": Operator, "|": Operator, "^": Operator, "<<": Operator, ">>": Operator,
+	"&^": Operator, "+=": Operator, "-=": Operator, "*=": Operator, "/=": Operator,
+	"%=": Operator, "&=": Operator, "|=": Operator, "^=": Operator, "<<=": Operator,
+	">>=": Operator, "&^=": Operator, "&&": Operator, "||": Operator, "<-": Operator,
+	"++": Operator, "--": Operator, "==": Operator, "<": Operator, ">": Operator,
+	"=": Operator, "!": Operator, "~": Operator, "!=": Operator, "<=": Operator,
+	">=": Operator, ":=": Operator, "...": Operator, "(": Operator, ")": Operator,
+	"[": Operator, "]": Operator, "{": Operator, "}": Operator, ",": Operator,
+	".": Operator, ":": Operator, ";": Semicolon,
+})
+
+var numberTypes = scan.NumberTypes{
+	Int:         Int,
+	Hex:         Int,
+	Octal:       Int,
+	Binary:      Int,
+	Float:       Float,
+	Underscores: true,
+}
+
+// blanks is the white space other than line breaks.
+var blanks = scan.Whitespace{IsSpace: func(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r'
+}}
+
+// endsStatement reports whether a line break after item ends a statement,
+// following the rules of the Go specification.
+func endsStatement(item scan.Item) bool {
+	switch item.Typ {
+	case Ident, Int, Float, Char, String:
+		return true
+	case Keyword:
+		switch item.Val {
+		case "break", "continue", "fallthrough", "return":
+			return true
+		}
+	case Operator:
+		switch item.Val {
+		case "++", "--", ")", "]", "}":
+			return true
+		}
+	}
+	return false
+}
+
+// insertSemicolon emits a semicolon, with the pending text as its value,
+// if the last item ends a statement, and ignores the pending text
+// otherwise.
+func insertSemicolon(s *scan.Scanner) {
+	if last, ok := s.LastItem(); ok && endsStatement(last) {
+		s.Emit(Semicolon)
+	} else {
+		s.Ignore()
+	}
+}
+
+// Lex is the initial state of the Go lexer. It scans any token.
+func Lex(s *scan.Scanner) scan.StateFn {
+	s.SkipSpace(blanks)
+	if s.SkipLineComment("//") {
+		return Lex
+	}
+	c := s.Mark()
+	if s.Next() == '/' && s.Peek() == '*' {
+		return lexGeneralComment
+	}
+	s.Rewind(c)
+	switch r := s.Peek(); {
+	case r == scan.EOF:
+		insertSemicolon(s)
+		s.Emit(scan.EOF)
+		return nil
+	case r == '\n':
+		s.Next()
+		insertSemicolon(s)
+		return Lex
+	case r == '_' || unicode.IsLetter(r):
+		for r := s.Peek(); r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r); r = s.Peek() {
+			s.Next()
+		}
+		if t, ok := keywords.Lookup(s.Text()); ok {
+			s.Emit(t)
+		} else {
+			s.Emit(Ident)
+		}
+		return Lex
+	case '0' <= r && r <= '9':
+		return scan.LexNumber(numberTypes, Lex)
+	case r == '.':
+		c := s.Mark()
+		s.Next()
+		isNumber := '0' <= s.Peek() && s.Peek() <= '9'
+		s.Rewind(c)
+		if isNumber {
+			return scan.LexNumber(numberTypes, Lex)
+		}
+	case r == '\'':
+		return scan.LexQuoted('\'', true, Char, Lex)
+	case r == '"':
+		return scan.LexQuoted('"', true, String, Lex)
+	case r == '`':
+		return scan.LexQuoted('`', false, String, Lex)
+	}
+	if t, ok := operators.Match(s); ok {
+		s.Emit(t)
+		return Lex
+	}
+	return s.Errorf("unexpected character %q", s.Next())
+}
+
+// lexGeneralComment skips a general comment after its initial '/'. A
+// comment containing line breaks acts like a line break.
+func lexGeneralComment(s *scan.Scanner) scan.StateFn {
+	s.Next() // '*'
+	for prev, r := rune(0), s.Next(); prev != '*' || r != '/'; prev, r = r, s.Next() {
+		if r == scan.EOF {
+			return s.Errorf("unterminated comment")
+		}
+	}
+	multiline := strings.Contains(s.Text(), "\n")
+	s.Ignore()
+	if multiline {
+		insertSemicolon(s)
+	}
+	return Lex
+}
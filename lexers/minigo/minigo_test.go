@@ -0,0 +1,107 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package minigo
+
+import (
+	"testing"
+
+	"github.com/schulze/scan"
+	"github.com/schulze/scan/fuzztest"
+	"github.com/schulze/scan/scantest"
+)
+
+func init() {
+	for t, name := range Names {
+		scan.RegisterTypeName(t, name)
+	}
+}
+
+type lexTest struct {
+	name  string
+	input string
+	items []scan.Item
+}
+
+var lexTests = []lexTest{
+	{"empty", "", []scan.Item{
+		{Typ: scan.EOF, Pos: 0},
+	}},
+	{"statements", "x := 1_0\ny++ // c\nreturn\n", []scan.Item{
+		{Typ: Ident, Pos: 0, Val: "x"},
+		{Typ: Operator, Pos: 2, Val: ":="},
+		{Typ: Int, Pos: 5, Val: "1_0"},
+		{Typ: Semicolon, Pos: 8, Val: "\n"},
+		{Typ: Ident, Pos: 9, Val: "y"},
+		{Typ: Operator, Pos: 10, Val: "++"},
+		{Typ: Semicolon, Pos: 17, Val: "\n"},
+		{Typ: Keyword, Pos: 18, Val: "return"},
+		{Typ: Semicolon, Pos: 24, Val: "\n"},
+		{Typ: scan.EOF, Pos: 25},
+	}},
+	{"no insertion", "if x {\n\tf(a,\n\t\tb); }", []scan.Item{
+		{Typ: Keyword, Pos: 0, Val: "if"},
+		{Typ: Ident, Pos: 3, Val: "x"},
+		{Typ: Operator, Pos: 5, Val: "{"},
+		{Typ: Ident, Pos: 8, Val: "f"},
+		{Typ: Operator, Pos: 9, Val: "("},
+		{Typ: Ident, Pos: 10, Val: "a"},
+		{Typ: Operator, Pos: 11, Val: ","},
+		{Typ: Ident, Pos: 15, Val: "b"},
+		{Typ: Operator, Pos: 16, Val: ")"},
+		{Typ: Semicolon, Pos: 17, Val: ";"},
+		{Typ: Operator, Pos: 19, Val: "}"},
+		{Typ: Semicolon, Pos: 20, Val: ""},
+		{Typ: scan.EOF, Pos: 20},
+	}},
+	{"literals", "'a' \"b\\\"\" `c\n` .5 /* */ f /*\n*/ g", []scan.Item{
+		{Typ: Char, Pos: 0, Val: "'a'"},
+		{Typ: String, Pos: 4, Val: "\"b\\\"\""},
+		{Typ: String, Pos: 10, Val: "`c\n`"},
+		{Typ: Float, Pos: 15, Val: ".5"},
+		{Typ: Ident, Pos: 24, Val: "f"},
+		{Typ: Semicolon, Pos: 31, Val: ""},
+		{Typ: Ident, Pos: 32, Val: "g"},
+		{Typ: Semicolon, Pos: 33, Val: ""},
+		{Typ: scan.EOF, Pos: 33},
+	}},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		t.Run(test.name, func(t *testing.T) {
+			scantest.RequireItems(t, scantest.Collect(New(test.name, test.input)), test.items, 0)
+		})
+	}
+}
+
+type errorTest struct {
+	input string
+	err   string
+}
+
+var errorTests = []errorTest{
+	{"a /*/", "unterminated comment"},
+	{"a # b", `unexpected character '#'`},
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range errorTests {
+		items := scantest.Collect(New(test.input, test.input))
+		if got := items[len(items)-2]; got.Typ != scan.ERROR || got.Val != test.err {
+			t.Errorf("%q: got %v, expected error %q", test.input, got, test.err)
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	seeds := []string{}
+	for _, test := range lexTests {
+		seeds = append(seeds, test.input)
+	}
+	for _, test := range errorTests {
+		seeds = append(seeds, test.input)
+	}
+	fuzztest.Config{Start: Lex}.Fuzz(f, seeds...)
+}
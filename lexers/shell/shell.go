@@ -0,0 +1,202 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package shell is a lexer for the token syntax of the POSIX shell command
+// language, written with package scan. It splits its input into words,
+// operators, comments and line breaks, following the quoting rules of the
+// shell: backslash escapes, single quotes and double quotes. Parameter
+// expansion, command substitution and here-documents are not recognized;
+// "$x" and "`cmd`" are ordinary parts of words.
+//
+// The lexer switches between quoting contexts with one state function per
+// context: a quote inside a word moves to the state for quoted text, which
+// returns to the word state at the closing quote. A word thus extends over
+// all its quoted and unquoted parts, as in the shell.
+//
+// Split uses the lexer to split a command line into arguments.
+package shell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/schulze/scan"
+)
+
+// Item types of shell tokens.
+const (
+	Word     scan.ItemType = iota // word, as written, including quotes and backslashes; see Unquote
+	Operator                      // control or redirection operator, such as "|", "&&" or ">>"
+	Comment                       // comment, including the '#'
+	Newline                       // line break
+)
+
+// Names holds the names of the item types, for use with
+// scan.RegisterTypeName.
+var Names = map[scan.ItemType]string{
+	Word:     "Word",
+	Operator: "Operator",
+	Comment:  "Comment",
+	Newline:  "Newline",
+}
+
+// New returns a scanner for the shell text input.
+func New(name, input string, opts ...scan.Option) *scan.Scanner {
+	return scan.New(name, input, Lex, opts...)
+}
+
+// operatorChars are the characters that start operators and end words.
+const operatorChars = "|&;()<>"
+
+var operators = scan.NewOperatorTable(map[string]scan.ItemType{
+	"|": Operator, "&": Operator, ";": Operator, "(": Operator, ")": Operator,
+	"<": Operator, ">": Operator,
+	"&&": Operator, "||": Operator, ";;": Operator,
+	"<<": Operator, ">>": Operator, "<&": Operator, ">&": Operator,
+	"<>": Operator, ">|": Operator, "<<-": Operator,
+})
+
+// Lex is the initial state of the shell lexer. It scans any token.
+func Lex(s *scan.Scanner) scan.StateFn {
+	for {
+		s.SkipSpace(scan.Blanks)
+		// A backslash-newline outside quotes joins lines.
+		c := s.Mark()
+		if s.Next() != '\\' || s.Next() != '\n' {
+			s.Rewind(c)
+			break
+		}
+		s.Ignore()
+	}
+	switch r := s.Peek(); {
+	case r == scan.EOF:
+		s.Emit(scan.EOF)
+		return nil
+	case r == '\n':
+		s.Next()
+		s.Emit(Newline)
+		return Lex
+	case r == '#':
+		for r := s.Peek(); r != '\n' && r != scan.EOF; r = s.Peek() {
+			s.Next()
+		}
+		s.Emit(Comment)
+		return Lex
+	case strings.ContainsRune(operatorChars, r):
+		t, _ := operators.Match(s)
+		s.Emit(t)
+		return Lex
+	}
+	return lexWord
+}
+
+// lexWord scans the unquoted parts of a word.
+func lexWord(s *scan.Scanner) scan.StateFn {
+	for {
+		switch r := s.Next(); {
+		case r == scan.EOF || r == ' ' || r == '\t' || r == '\n' || strings.ContainsRune(operatorChars, r):
+			s.Backup()
+			s.Emit(Word)
+			return Lex
+		case r == '\\':
+			if s.Next() == scan.EOF {
+				return s.Errorf("backslash at end of input")
+			}
+		case r == '\'':
+			return lexSingleQuoted
+		case r == '"':
+			return lexDoubleQuoted
+		}
+	}
+}
+
+// lexSingleQuoted scans the rest of a single-quoted part of a word, in
+// which every character stands for itself.
+func lexSingleQuoted(s *scan.Scanner) scan.StateFn {
+	for {
+		switch s.Next() {
+		case '\'':
+			return lexWord
+		case scan.EOF:
+			return s.Errorf("unterminated single-quoted string")
+		}
+	}
+}
+
+// lexDoubleQuoted scans the rest of a double-quoted part of a word, in
+// which a backslash escapes the next character.
+func lexDoubleQuoted(s *scan.Scanner) scan.StateFn {
+	for {
+		switch s.Next() {
+		case '"':
+			return lexWord
+		case '\\':
+			s.Next()
+		case scan.EOF:
+			return s.Errorf("unterminated double-quoted string")
+		}
+	}
+}
+
+// Unquote returns the value of a word scanned by the lexer, after quote
+// removal: quotes are removed, as are backslashes that escape a character.
+// Inside double quotes, a backslash escapes only '$', '`', '"', '\\' and
+// the line break.
+func Unquote(word string) string {
+	var b strings.Builder
+	for i := 0; i < len(word); i++ {
+		switch c := word[i]; c {
+		case '\\':
+			if i++; i < len(word) && word[i] != '\n' {
+				b.WriteByte(word[i])
+			}
+		case '\'':
+			j := strings.IndexByte(word[i+1:], '\'')
+			if j < 0 {
+				j = len(word) - i - 1
+			}
+			b.WriteString(word[i+1 : i+1+j])
+			i += j + 1
+		case '"':
+			for i++; i < len(word) && word[i] != '"'; i++ {
+				if word[i] == '\\' && i+1 < len(word) && strings.IndexByte("$`\"\\\n", word[i+1]) >= 0 {
+					if i++; word[i] == '\n' {
+						continue
+					}
+				}
+				b.WriteByte(word[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Split splits a command line into its words, after quote removal, like
+// the shell before it runs a simple command. Line breaks separate words
+// like blanks, and comments are ignored. Operators are not allowed.
+func Split(line string) ([]string, error) {
+	s := New("", line)
+	var (
+		words []string
+		err   error
+	)
+	// Read up to EOF even after an error to let the scanner finish.
+	for item := s.NextItem(); item.Typ != scan.EOF; item = s.NextItem() {
+		switch {
+		case err != nil:
+		case item.Typ == scan.ERROR:
+			err = fmt.Errorf("%v: %w", s.Position(item.Pos), item.Err())
+		case item.Typ == Operator:
+			err = fmt.Errorf("%v: unexpected operator %q", s.Position(item.Pos), item.Val)
+		case item.Typ == Word:
+			words = append(words, Unquote(item.Val))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return words, nil
+}
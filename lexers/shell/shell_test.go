@@ -0,0 +1,128 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shell
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/schulze/scan"
+	"github.com/schulze/scan/fuzztest"
+	"github.com/schulze/scan/scantest"
+)
+
+func init() {
+	for t, name := range Names {
+		scan.RegisterTypeName(t, name)
+	}
+}
+
+type lexTest struct {
+	name  string
+	input string
+	items []scan.Item
+}
+
+var lexTests = []lexTest{
+	{"empty", "", []scan.Item{
+		{Typ: scan.EOF, Pos: 0},
+	}},
+	{"pipeline", "ls -l|wc>>out&&x # c\n", []scan.Item{
+		{Typ: Word, Pos: 0, Val: "ls"},
+		{Typ: Word, Pos: 3, Val: "-l"},
+		{Typ: Operator, Pos: 5, Val: "|"},
+		{Typ: Word, Pos: 6, Val: "wc"},
+		{Typ: Operator, Pos: 8, Val: ">>"},
+		{Typ: Word, Pos: 10, Val: "out"},
+		{Typ: Operator, Pos: 13, Val: "&&"},
+		{Typ: Word, Pos: 15, Val: "x"},
+		{Typ: Comment, Pos: 17, Val: "# c"},
+		{Typ: Newline, Pos: 20, Val: "\n"},
+		{Typ: scan.EOF, Pos: 21},
+	}},
+	{"quotes", `a'b c'"d;\"e"\ f#g`, []scan.Item{
+		{Typ: Word, Pos: 0, Val: `a'b c'"d;\"e"\ f#g`},
+		{Typ: scan.EOF, Pos: 18},
+	}},
+	{"continuation", "a \\\nb<<-c", []scan.Item{
+		{Typ: Word, Pos: 0, Val: "a"},
+		{Typ: Word, Pos: 4, Val: "b"},
+		{Typ: Operator, Pos: 5, Val: "<<-"},
+		{Typ: Word, Pos: 8, Val: "c"},
+		{Typ: scan.EOF, Pos: 9},
+	}},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		t.Run(test.name, func(t *testing.T) {
+			scantest.RequireItems(t, scantest.Collect(New(test.name, test.input)), test.items, 0)
+		})
+	}
+}
+
+var unquoteTests = []struct {
+	word, want string
+}{
+	{`abc`, `abc`},
+	{`a'b c'"d;\"e"\ f#g`, `ab cd;"e f#g`},
+	{`'\'`, `\`},
+	{`"\a\$\\"`, `\a$\`},
+	{"\"a\\\nb\"", "ab"},
+	{"a\\\nb", "ab"},
+}
+
+func TestUnquote(t *testing.T) {
+	for _, test := range unquoteTests {
+		if got := Unquote(test.word); got != test.want {
+			t.Errorf("%q: got %q, expected %q", test.word, got, test.want)
+		}
+	}
+}
+
+type splitTest struct {
+	line  string
+	words []string
+	err   string
+}
+
+var splitTests = []splitTest{
+	{"", nil, ""},
+	{"cp -r 'my dir' \"$HOME/x\" # copy\n  y", []string{"cp", "-r", "my dir", "$HOME/x", "y"}, ""},
+	{"a 'b", nil, "1:3: unterminated single-quoted string"},
+	{"a \"b", nil, "1:3: unterminated double-quoted string"},
+	{"a\\", nil, "1:1: backslash at end of input"},
+	{"a\nb | c", nil, `2:3: unexpected operator "|"`},
+}
+
+func TestSplit(t *testing.T) {
+	for _, test := range splitTests {
+		words, err := Split(test.line)
+		if !reflect.DeepEqual(words, test.words) {
+			t.Errorf("%q: got\n\t%q\nexpected\n\t%q", test.line, words, test.words)
+		}
+		if errString(err) != test.err {
+			t.Errorf("%q: got error %q, expected %q", test.line, errString(err), test.err)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func FuzzLex(f *testing.F) {
+	seeds := []string{}
+	for _, test := range lexTests {
+		seeds = append(seeds, test.input)
+	}
+	for _, test := range splitTests {
+		seeds = append(seeds, test.line)
+	}
+	fuzztest.Config{Start: Lex}.Fuzz(f, seeds...)
+}
@@ -0,0 +1,156 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInputTooLong is carried by the error item that terminates a scan whose
+// input exceeds the limit set with MaxInputLen.
+var ErrInputTooLong = errors.New("input too long")
+
+// ErrTokenTooLong is carried by the error item that replaces an item
+// exceeding the limit set with MaxTokenLen.
+var ErrTokenTooLong = errors.New("token too long")
+
+// ErrTooDeep is carried by the error item that terminates a scan whose
+// nesting depth exceeds the limit set with MaxNesting.
+var ErrTooDeep = errors.New("nesting too deep")
+
+// ErrTimeBudget is carried by the error item that terminates a scan that
+// exceeded the budget set with TimeBudget.
+var ErrTimeBudget = errors.New("time budget exceeded")
+
+// MaxInputLen limits the length of the input, including text inserted with
+// Include, to n bytes, to protect services that scan untrusted input. A
+// longer input is not scanned; instead the scanner passes back an error
+// item carrying ErrInputTooLong, positioned at the limit. A limit of 0,
+// the default, means no limit.
+func MaxInputLen(n int) Option {
+	return func(s *Scanner) {
+		s.maxInputLen = n
+	}
+}
+
+// MaxTokenLen limits the length of the values of items to n bytes. An item
+// with a longer value is replaced by an error item carrying
+// ErrTokenTooLong at its position, and the scan continues. Error and
+// warning items are not limited. A limit of 0, the default, means no
+// limit.
+func MaxTokenLen(n int) Option {
+	return func(s *Scanner) {
+		s.maxTokenLen = n
+	}
+}
+
+// checkInputLen terminates the scan with an error if the input exceeds the
+// limit set with MaxInputLen, and reports whether it did.
+func (s *Scanner) checkInputLen() bool {
+	if s.maxInputLen <= 0 || len(s.input) <= s.maxInputLen {
+		return false
+	}
+	p := Pos(s.maxInputLen)
+	s.errorAt(p, fmt.Errorf("%w: %d bytes exceed the limit of %d at %s", ErrInputTooLong, len(s.input), s.maxInputLen, s.lineCol(p)))
+	s.stopped = true
+	return true
+}
+
+// tokenTooLong reports an error and returns true if item exceeds the limit
+// set with MaxTokenLen.
+func (s *Scanner) tokenTooLong(item Item) bool {
+	if s.maxTokenLen <= 0 || len(item.Val) <= s.maxTokenLen || item.Typ == ERROR || item.Typ == WARNING {
+		return false
+	}
+	s.errorAt(item.Pos, fmt.Errorf("%w: %d bytes exceed the limit of %d at %s", ErrTokenTooLong, len(item.Val), s.maxTokenLen, s.lineCol(item.Pos)))
+	return true
+}
+
+// MaxNesting limits the nesting depth tracked with EnterNesting to n
+// levels, to protect services that scan untrusted input from adversarial
+// deeply nested comments, brackets or interpolations. A limit of 0, the
+// default, means no limit.
+func MaxNesting(n int) Option {
+	return func(s *Scanner) {
+		s.maxNesting = n
+	}
+}
+
+// EnterNesting increments the nesting depth, as a state function does when
+// it opens a nested construct. If the depth exceeds the limit set with
+// MaxNesting, EnterNesting emits an error item carrying ErrTooDeep,
+// terminates the scan and returns false.
+func (s *Scanner) EnterNesting() bool {
+	return s.enterNestingAt(s.start)
+}
+
+// enterNestingAt is EnterNesting for a construct opened at p.
+func (s *Scanner) enterNestingAt(p Pos) bool {
+	s.nesting++
+	if s.maxNesting <= 0 || s.nesting <= s.maxNesting {
+		return true
+	}
+	s.errorAt(p, fmt.Errorf("%w: more than %d levels at %s", ErrTooDeep, s.maxNesting, s.lineCol(p)))
+	root := s
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.stopped = true
+	return false
+}
+
+// LeaveNesting decrements the nesting depth, as a state function does when
+// it closes a nested construct.
+func (s *Scanner) LeaveNesting() {
+	if s.nesting > 0 {
+		s.nesting--
+	}
+}
+
+// Nesting returns the current nesting depth.
+func (s *Scanner) Nesting() int {
+	return s.nesting
+}
+
+// TimeBudget makes the scanner terminate with an error item carrying
+// ErrTimeBudget when the scan takes longer than total, or when more than
+// perItem passes between two items, as a circuit breaker for services
+// exposed to inputs that trigger quadratic lexer behavior. The budgets are
+// checked after each state function returns, so a state function that
+// never returns is not interrupted. Time the scanner spends waiting for
+// the client to take items counts towards total only. A budget of 0, the
+// default, means no limit.
+func TimeBudget(total, perItem time.Duration) Option {
+	return func(s *Scanner) {
+		s.timeBudget, s.itemBudget = total, perItem
+	}
+}
+
+// overBudget terminates the scan with an error if it exceeded the budgets
+// set with TimeBudget, and reports whether it did.
+func (s *Scanner) overBudget() bool {
+	root := s
+	for root.parent != nil {
+		root = root.parent
+	}
+	if root.timeBudget <= 0 && root.itemBudget <= 0 {
+		return false
+	}
+	now := time.Now()
+	var budget time.Duration
+	switch {
+	case root.timeBudget > 0 && now.Sub(root.started) > root.timeBudget:
+		budget = root.timeBudget
+	case root.itemBudget > 0 && now.Sub(root.lastItem) > root.itemBudget:
+		budget = root.itemBudget
+	default:
+		return false
+	}
+	s.errorAt(s.pos, fmt.Errorf("%w: %v at %s", ErrTimeBudget, budget, s.lineCol(s.pos)))
+	root.stopped = true
+	return true
+}
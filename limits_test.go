@@ -0,0 +1,150 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type limitTest struct {
+	name  string
+	input string
+	opts  []Option
+	items []Item
+}
+
+var limitTests = []limitTest{
+	{"no limits", "abc de", nil, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "abc"},
+		{Typ: SPACE, Pos: 3, Val: " "},
+		{Typ: IDENTIFIER, Pos: 4, Val: "de"},
+		{Typ: EOF, Pos: 6, Val: ""},
+	}},
+	{"token", "abc de", []Option{MaxTokenLen(2)}, []Item{
+		{Typ: ERROR, Pos: 0, Val: "token too long: 3 bytes exceed the limit of 2 at 1:1"},
+		{Typ: SPACE, Pos: 3, Val: " "},
+		{Typ: IDENTIFIER, Pos: 4, Val: "de"},
+		{Typ: EOF, Pos: 6, Val: ""},
+	}},
+	{"input", "abc\nde", []Option{MaxInputLen(5)}, []Item{
+		{Typ: ERROR, Pos: 5, Val: "input too long: 6 bytes exceed the limit of 5 at 2:2"},
+		{Typ: EOF, Pos: 6, Val: ""},
+	}},
+	{"input within limit", "abc", []Option{MaxInputLen(3)}, []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "abc"},
+		{Typ: EOF, Pos: 3, Val: ""},
+	}},
+}
+
+func TestLimits(t *testing.T) {
+	for _, test := range limitTests {
+		items := drain(New(test.name, test.input, lexIdents, test.opts...))
+		if !equal(items, test.items, true) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+func TestMaxInputLenInclude(t *testing.T) {
+	lex := func(s *Scanner) StateFn {
+		s.Include("inc", "xyz")
+		return lexIdents
+	}
+	items := drain(New("include", "ab", lex, MaxInputLen(4)))
+	if len(items) != 2 || !errors.Is(items[0].Err(), ErrInputTooLong) {
+		t.Errorf("got %v, expected an ErrInputTooLong error", items)
+	}
+}
+
+// lexNested scans parenthesized groups, tracking their nesting depth.
+func lexNested(s *Scanner) StateFn {
+	switch s.Next() {
+	case EOF:
+		s.Emit(EOF)
+		return nil
+	case '(':
+		if !s.EnterNesting() {
+			return nil
+		}
+		s.Emit(LPAREN)
+	case ')':
+		s.LeaveNesting()
+		s.Emit(RPAREN)
+	default:
+		s.Emit(IDENTIFIER)
+	}
+	return lexNested
+}
+
+func TestMaxNesting(t *testing.T) {
+	items := drain(New("nesting", "(()((x)))", lexNested, MaxNesting(3)))
+	if len(items) != 10 {
+		t.Errorf("within limit: got %v", items)
+	}
+	items = drain(New("nesting", "(((()", lexNested, MaxNesting(3)))
+	expect := []Item{
+		{Typ: LPAREN, Pos: 0, Val: "("},
+		{Typ: LPAREN, Pos: 1, Val: "("},
+		{Typ: LPAREN, Pos: 2, Val: "("},
+		{Typ: ERROR, Pos: 3, Val: "nesting too deep: more than 3 levels at 1:4"},
+		{Typ: EOF, Pos: 5, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
+
+func TestTimeBudget(t *testing.T) {
+	slow := func(d time.Duration) StateFn {
+		return func(s *Scanner) StateFn {
+			time.Sleep(d)
+			return lexIdents
+		}
+	}
+	tests := []struct {
+		name  string
+		start StateFn
+		opt   Option
+		err   bool
+	}{
+		{"within budget", slow(0), TimeBudget(time.Minute, time.Minute), false},
+		{"total", slow(20 * time.Millisecond), TimeBudget(time.Millisecond, 0), true},
+		{"per item", slow(20 * time.Millisecond), TimeBudget(0, time.Millisecond), true},
+	}
+	for _, test := range tests {
+		items := drain(New(test.name, "a b", test.start, test.opt))
+		err := errors.Is(items[0].Err(), ErrTimeBudget)
+		if err != test.err {
+			t.Errorf("%s: got %v", test.name, items)
+		}
+	}
+}
+
+func TestMaxNestingSubScan(t *testing.T) {
+	var depth int
+	// lexOuter sub-scans the brackets at the start of the input and then
+	// scans the rest itself.
+	lexOuter := func(s *Scanner) StateFn {
+		s.SubScan(0, 2, lexNested)
+		depth = s.Nesting()
+		s.Seek(2)
+		return lexNested
+	}
+	items := drain(New("sub", "(( a", lexOuter, MaxNesting(1)))
+	expect := []Item{
+		{Typ: LPAREN, Pos: 0, Val: "("},
+		{Typ: ERROR, Pos: 1, Val: "nesting too deep: more than 1 levels at 1:2"},
+		{Typ: EOF, Pos: 4, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+	drain(New("sub", "(( a", lexOuter))
+	if depth != 2 {
+		t.Errorf("got nesting %d after the sub-scan, expected 2", depth)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LineDirectives makes the scanner honor line directives in the input, so
+// that lexers for generated or preprocessed sources report positions in
+// the original source. A directive occupies a line of its own, starting in
+// column 1, in one of the forms
+//
+//	//line filename:line
+//	//line filename:line:column
+//	#line line
+//	#line line "filename"
+//
+// and sets the source name and line number of the line following it. The
+// column of the Go form is ignored, and a directive without a file name
+// keeps the one of the preceding directive or of the input. The
+// directives apply to Position, LineNumber and the positions in error
+// messages; lexers still have to skip them like comments.
+func LineDirectives() Option {
+	return func(s *Scanner) {
+		s.directives = true
+	}
+}
+
+// applyLineDirective adjusts the name and line of the position at the
+// line starting at lineStart in text for the last line directive before it.
+func (s *Scanner) applyLineDirective(text string, lineStart int, name string, line int) (string, int) {
+	for end := lineStart; end > 0; {
+		start, prev := s.newlines.prevLine(text, end)
+		dname, dline, ok := parseLineDirective(strings.TrimSuffix(prev, "\r"))
+		if ok {
+			next, _ := s.newlines.lineOf(text, end)
+			if dname == "" {
+				dname, _ = s.applyLineDirective(text, start, name, 1)
+			}
+			return dname, dline + line - next
+		}
+		end = start
+	}
+	return name, line
+}
+
+// parseLineDirective returns the file name and line number set by the
+// directive on line, if it is one. The name is empty if the directive does
+// not set it.
+func parseLineDirective(line string) (name string, n int, ok bool) {
+	switch {
+	case strings.HasPrefix(line, "//line "):
+		rest := strings.TrimSpace(line[len("//line "):])
+		i := strings.LastIndexByte(rest, ':')
+		if i < 0 {
+			return "", 0, false
+		}
+		n, err := strconv.Atoi(rest[i+1:])
+		if err != nil {
+			return "", 0, false
+		}
+		if j := strings.LastIndexByte(rest[:i], ':'); j >= 0 {
+			if m, err := strconv.Atoi(rest[j+1 : i]); err == nil {
+				i, n = j, m // filename:line:column
+			}
+		}
+		name = rest[:i]
+		ok = n > 0
+		return name, n, ok
+	case strings.HasPrefix(line, "#line "):
+		fields := strings.SplitN(strings.TrimSpace(line[len("#line "):]), " ", 2)
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n <= 0 {
+			return "", 0, false
+		}
+		if len(fields) == 2 {
+			if name, err = strconv.Unquote(strings.TrimSpace(fields[1])); err != nil {
+				return "", 0, false
+			}
+		}
+		return name, n, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,76 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestLineDirectives(t *testing.T) {
+	const input = "a\n" +
+		"//line gen.y:10\n" +
+		"b\n" +
+		"\n" +
+		"c\n" +
+		"#line 3 \"orig.c\"\r\n" +
+		"d\n" +
+		"//line :20:5\n" +
+		"e\n" +
+		"  #line 99\n" +
+		"f\n" +
+		"//line bad\n" +
+		"g"
+	tests := []struct {
+		text string
+		pos  string
+	}{
+		{"a", "src:1:1"},
+		{"b", "gen.y:10:1"},
+		{"c", "gen.y:12:1"},
+		{"d", "orig.c:3:1"},
+		{"e", "orig.c:20:1"},
+		{"f", "orig.c:22:1"},
+		{"g", "orig.c:24:1"},
+	}
+	s := New("src", input, nil, LineDirectives())
+	plain := New("src", input, nil)
+	for _, test := range tests {
+		p := Pos(len(input) - 1)
+		for i := 0; i < len(input); i++ {
+			if input[i:i+1] == test.text && (i == 0 || input[i-1] == '\n') {
+				p = Pos(i)
+				break
+			}
+		}
+		if pos := s.Position(p).String(); pos != test.pos {
+			t.Errorf("%s: got %s, expected %s", test.text, pos, test.pos)
+		}
+		if pos := plain.Position(p); pos.Name != "src" {
+			t.Errorf("%s: got %s without LineDirectives", test.text, pos)
+		}
+	}
+}
+
+func TestLineDirectiveErrors(t *testing.T) {
+	const input = "#line 7 \"orig.c\"\n y"
+	lexX := func(s *Scanner) StateFn {
+		s.Seek(Pos(len(input) - 1))
+		s.Expect('x')
+		return nil
+	}
+	items := drain(New("src", input, lexX, LineDirectives()))
+	expected := "expected 'x', found 'y' at 7:2"
+	if items[0].Typ != ERROR || items[0].Val != expected {
+		t.Errorf("got %v, expected error %q", items[0], expected)
+	}
+}
+
+func TestLineDirectivesNewlineAny(t *testing.T) {
+	const input = "a\r//line gen.y:10\rb\r\nc"
+	s := New("src", input, nil, LineDirectives(), Newlines(NewlineAny))
+	for p, expected := range map[Pos]string{0: "src:1:1", 18: "gen.y:10:1", 21: "gen.y:11:1"} {
+		if pos := s.Position(p).String(); pos != expected {
+			t.Errorf("%d: got %s, expected %s", p, pos, expected)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LexQuoted returns a state function that scans a string enclosed in quote
+// runes, starting with the opening quote at the current position, emits it
+// as an item of type t, including the quotes, and continues with next. If
+// escapes is true, a backslash escapes the following rune, so that an
+// escaped quote does not end the string. The escapes are not interpreted;
+// see strconv.Unquote for that. An unterminated string is an error.
+func LexQuoted(quote rune, escapes bool, t ItemType, next StateFn) StateFn {
+	return func(s *Scanner) StateFn {
+		if !s.Expect(quote) {
+			return nil
+		}
+		for {
+			switch s.Next() {
+			case '\\':
+				if escapes && s.Next() == EOF {
+					s.errorAt(s.start, s.unterminated("quoted string"))
+					return nil
+				}
+			case quote:
+				s.Emit(t)
+				return next
+			case EOF:
+				s.errorAt(s.start, s.unterminated("quoted string"))
+				return nil
+			}
+		}
+	}
+}
+
+// LexHeredoc returns a state function that scans a here-document
+// starting with "<<" at the current position, emits it as an item of type
+// t and continues with next. The "<<" is followed by a tag made of letters,
+// digits and underscores and the end of the line. The body consists of the
+// following lines up to a line consisting of the tag alone, which ends the
+// here-document; the newline after it is left to be scanned next. In the
+// variant starting with "<<-", leading tabs are removed from the lines of
+// the body and from the terminating line, as in POSIX shells. The value of
+// the item is the body, including the newline of its last line; its
+// position is that of the "<<".
+func LexHeredoc(t ItemType, next StateFn) StateFn {
+	return func(s *Scanner) StateFn {
+		if !s.ExpectString("<<") {
+			return nil
+		}
+		strip := s.Accept("-")
+		tagStart := s.pos
+		for isWordRune(s.Peek()) {
+			s.Next()
+		}
+		tag := s.input[tagStart:s.pos]
+		if tag == "" {
+			s.errorAt(s.pos, fmt.Errorf("missing here-document tag at %s", s.lineCol(s.pos)))
+			return nil
+		}
+		s.AcceptRun(" \t")
+		if !s.Expect('\n') {
+			return nil
+		}
+		var body strings.Builder
+		for {
+			rest := s.input[s.pos:]
+			if rest == "" {
+				s.errorAt(s.start, s.unterminated("here-document"))
+				return nil
+			}
+			line := rest
+			if i := strings.IndexByte(rest, '\n'); i >= 0 {
+				line = rest[:i+1]
+			}
+			s.pos += Pos(len(line))
+			if strip {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if strings.TrimSuffix(line, "\n") == tag {
+				if strings.HasSuffix(line, "\n") {
+					s.pos--
+				}
+				break
+			}
+			body.WriteString(line)
+		}
+		s.width = 0
+		s.emit(Item{Typ: t, Pos: s.start, Val: body.String()}.withEnd(s.pos))
+		s.start = s.pos
+		return next
+	}
+}
+
+// unterminated returns an error for a construct starting at the beginning
+// of the pending text that runs into the end of the input.
+func (s *Scanner) unterminated(what string) error {
+	return fmt.Errorf("unterminated %s starting at %s", what, s.lineCol(s.start))
+}
+
+// NumberTypes configures LexNumber. It holds the item types to emit for
+// the different kinds of numeric literals.
+type NumberTypes struct {
+	Int    ItemType // decimal integers such as 42
+	Hex    ItemType // hexadecimal integers such as 0x2a
+	Octal  ItemType // octal integers such as 0o52
+	Binary ItemType // binary integers such as 0b101010
+	Float  ItemType // floating-point numbers such as 4.2, .5 or 42e-1
+
+	// Underscores allows '_' as a separator between digits and after a
+	// base prefix, as in 1_000_000 or 0x_ff.
+	Underscores bool
+}
+
+// LexNumber returns a state function that scans a numeric literal
+// starting at the current position, emits it with the item type from
+// types that matches its kind and continues with next. Signs are not part
+// of the literal. A malformed literal, such as 0x without digits, 1e+
+// or a number directly followed by a letter, is an error.
+func LexNumber(types NumberTypes, next StateFn) StateFn {
+	return func(s *Scanner) StateFn {
+		typ, ok := s.scanNumber(types)
+		if !ok || isWordRune(s.Peek()) {
+			for isWordRune(s.Peek()) {
+				s.Next()
+			}
+			return s.Errorf("bad number syntax: %q", s.Text())
+		}
+		s.Emit(typ)
+		return next
+	}
+}
+
+// scanNumber consumes a numeric literal and returns its item type. It
+// reports false if the literal is malformed.
+func (s *Scanner) scanNumber(types NumberTypes) (ItemType, bool) {
+	if c := s.Mark(); s.Accept("0") {
+		var digits string
+		var typ ItemType
+		switch {
+		case s.Accept("xX"):
+			digits, typ = "0123456789abcdefABCDEF", types.Hex
+		case s.Accept("oO"):
+			digits, typ = "01234567", types.Octal
+		case s.Accept("bB"):
+			digits, typ = "01", types.Binary
+		}
+		if digits != "" {
+			return typ, s.acceptDigits(digits, types.Underscores, true) > 0
+		}
+		s.Rewind(c)
+	}
+	const decimal = "0123456789"
+	typ := types.Int
+	n := s.acceptDigits(decimal, types.Underscores, false)
+	if s.Accept(".") {
+		typ = types.Float
+		n += s.acceptDigits(decimal, types.Underscores, false)
+	}
+	if n == 0 {
+		return typ, false
+	}
+	if s.Accept("eE") {
+		typ = types.Float
+		s.Accept("+-")
+		if s.acceptDigits(decimal, types.Underscores, false) == 0 {
+			return typ, false
+		}
+	}
+	return typ, true
+}
+
+// acceptDigits consumes a run of digits from the given set, optionally
+// separated by underscores, and returns the number of digits. An underscore
+// must follow a digit, or the base prefix if afterPrefix is true, and must
+// be followed by a digit; otherwise acceptDigits returns 0.
+func (s *Scanner) acceptDigits(digits string, underscores, afterPrefix bool) int {
+	n := 0
+	sep := afterPrefix // an underscore may come next
+	for {
+		switch r := s.Peek(); {
+		case strings.IndexRune(digits, r) >= 0:
+			n++
+			sep = true
+		case r == '_' && underscores:
+			if !sep {
+				return 0
+			}
+			sep = false
+		default:
+			if !sep && n > 0 {
+				return 0 // trailing underscore
+			}
+			return n
+		}
+		s.Next()
+	}
+}
+
+// isWordRune reports whether r can be part of an identifier or number.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// EmitInt passes the pending input back to the client as an item of type
+// t like Emit and attaches its value as an int64, as parsed by
+// strconv.ParseInt with base 0, which accepts prefixes such as "0x" and
+// underscores. If the text cannot be parsed, the item carries the error
+// instead, with its position, which Err returns.
+func (s *Scanner) EmitInt(t ItemType) {
+	v, err := strconv.ParseInt(s.Text(), 0, 64)
+	s.emitDecoded(t, v, err)
+}
+
+// EmitFloat is like EmitInt but attaches the value as a float64, as parsed
+// by strconv.ParseFloat.
+func (s *Scanner) EmitFloat(t ItemType) {
+	v, err := strconv.ParseFloat(s.Text(), 64)
+	s.emitDecoded(t, v, err)
+}
+
+// EmitUnquoted is like EmitInt but attaches the value of a Go string or
+// character literal as a string, as interpreted by strconv.Unquote.
+func (s *Scanner) EmitUnquoted(t ItemType) {
+	v, err := strconv.Unquote(s.Text())
+	s.emitDecoded(t, v, err)
+}
+
+// emitDecoded emits the pending input with the decoded value v or, if
+// decoding failed, with err.
+func (s *Scanner) emitDecoded(t ItemType, v interface{}, err error) {
+	item := Item{Typ: t, Pos: s.start, Val: s.Text()}.withEnd(s.pos)
+	if err != nil {
+		item.err = fmt.Errorf("%w at %s", err, s.lineCol(s.start))
+	} else {
+		item.value = v
+	}
+	s.emit(item)
+	s.start = s.pos
+}
+
+// Value returns the value decoded from the text of an item emitted by
+// EmitInt, EmitFloat or EmitUnquoted, and nil for other items or if the
+// text could not be decoded.
+func (i Item) Value() interface{} {
+	return i.value
+}
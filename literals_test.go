@@ -0,0 +1,195 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const STRING = 300
+
+// lexStrings scans strings in double quotes with escapes and raw strings
+// in back quotes, separated by spaces.
+func lexStrings(s *Scanner) StateFn {
+	switch s.Peek() {
+	case EOF:
+		s.Emit(EOF)
+		return nil
+	case ' ':
+		s.Next()
+		s.Ignore()
+		return lexStrings
+	case '`':
+		return LexQuoted('`', false, STRING, lexStrings)
+	}
+	return LexQuoted('"', true, STRING, lexStrings)
+}
+
+var quotedTests = []lexTest{
+	{"empty", `""`, []Item{{Typ: STRING, Val: `""`}, tEOF}},
+	{"escapes", `"a\"b\\" "c"`, []Item{{Typ: STRING, Val: `"a\"b\\"`}, {Typ: STRING, Val: `"c"`}, tEOF}},
+	{"raw", "`a\\` \"\n\"", []Item{{Typ: STRING, Val: "`a\\`"}, {Typ: STRING, Val: "\"\n\""}, tEOF}},
+	{"unterminated", `"ab" "cd`, []Item{{Typ: STRING, Val: `"ab"`}, {Typ: ERROR, Val: "unterminated quoted string starting at 1:6"}, tEOF}},
+	{"escaped EOF", `"ab\`, []Item{{Typ: ERROR, Val: "unterminated quoted string starting at 1:1"}, tEOF}},
+	{"not a string", `x`, []Item{{Typ: ERROR, Val: `expected '"', found 'x' at 1:1`}, tEOF}},
+}
+
+func TestLexQuoted(t *testing.T) {
+	for _, test := range quotedTests {
+		items := drain(New(test.name, test.input, lexStrings))
+		if !equal(items, test.items, false) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+const (
+	DECIMAL = iota + 310
+	HEX
+	OCTAL
+	BINARY
+	FLOAT
+)
+
+var numberTypes = NumberTypes{
+	Int:         DECIMAL,
+	Hex:         HEX,
+	Octal:       OCTAL,
+	Binary:      BINARY,
+	Float:       FLOAT,
+	Underscores: true,
+}
+
+// lexNumbers scans numbers separated by spaces.
+func lexNumbers(s *Scanner) StateFn {
+	switch s.Peek() {
+	case EOF:
+		s.Emit(EOF)
+		return nil
+	case ' ':
+		s.Next()
+		s.Ignore()
+		return lexNumbers
+	}
+	return LexNumber(numberTypes, lexNumbers)
+}
+
+func TestLexNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		typ   ItemType
+	}{
+		{"0", DECIMAL},
+		{"1_000_000", DECIMAL},
+		{"0x_dead_BEEF", HEX},
+		{"0o755", OCTAL},
+		{"0b1010", BINARY},
+		{"4.2", FLOAT},
+		{".5", FLOAT},
+		{"1.", FLOAT},
+		{"6.02e+23", FLOAT},
+		{"1E9", FLOAT},
+		{"0x", ERROR},
+		{"0b102", ERROR},
+		{"1__0", ERROR},
+		{"1_", ERROR},
+		{"1e+", ERROR},
+		{"12ab", ERROR},
+		{"0o8", ERROR},
+	}
+	for _, test := range tests {
+		items := drain(New(test.input, test.input, lexNumbers))
+		if len(items) != 2 || items[0].Typ != test.typ {
+			t.Errorf("%q: got %v, expected a single item of type %v", test.input, items, test.typ)
+			continue
+		}
+		if test.typ == ERROR {
+			if expected := "bad number syntax: " + strconv.Quote(test.input); items[0].Val != expected {
+				t.Errorf("%q: got %q, expected %q", test.input, items[0].Val, expected)
+			}
+		} else if items[0].Val != test.input {
+			t.Errorf("%q: got value %q", test.input, items[0].Val)
+		}
+	}
+}
+
+const HEREDOC = 320
+
+// lexHeredocs scans here-documents separated by newlines.
+func lexHeredocs(s *Scanner) StateFn {
+	switch s.Peek() {
+	case EOF:
+		s.Emit(EOF)
+		return nil
+	case '\n':
+		s.Next()
+		s.Ignore()
+		return lexHeredocs
+	}
+	return LexHeredoc(HEREDOC, lexHeredocs)
+}
+
+var heredocTests = []lexTest{
+	{"plain", "<<EOT\na\n\tb\nEOT\n<<X  \nX", []Item{
+		{Typ: HEREDOC, Val: "a\n\tb\n"},
+		{Typ: HEREDOC, Val: ""},
+		tEOF,
+	}},
+	{"strip tabs", "<<-END\n\t\ta\n  b\n\tEND", []Item{{Typ: HEREDOC, Val: "a\n  b\n"}, tEOF}},
+	{"tag prefix", "<<END\nENDING\nEND\n", []Item{{Typ: HEREDOC, Val: "ENDING\n"}, tEOF}},
+	{"unterminated", "\n<<END\na\n", []Item{{Typ: ERROR, Val: "unterminated here-document starting at 2:1"}, tEOF}},
+	{"missing tag", "<< END\n", []Item{{Typ: ERROR, Val: "missing here-document tag at 1:3"}, tEOF}},
+	{"text after tag", "<<END x\n", []Item{{Typ: ERROR, Val: `expected '\n', found 'x' at 1:7`}, tEOF}},
+}
+
+func TestLexHeredoc(t *testing.T) {
+	for _, test := range heredocTests {
+		items := drain(New(test.name, test.input, lexHeredocs))
+		if !equal(items, test.items, false) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+func TestEmitDecoded(t *testing.T) {
+	lexLiterals := func(s *Scanner) StateFn {
+		for !s.AtEOF() {
+			s.IgnoreRun(" ")
+			r := s.Peek()
+			s.AcceptRun(`"\0123456789._abcdeftx`)
+			switch {
+			case r == '"':
+				s.EmitUnquoted(STRING)
+			case strings.ContainsAny(s.Text(), ".e") && !strings.HasPrefix(s.Text(), "0x"):
+				s.EmitFloat(INTEGER)
+			default:
+				s.EmitInt(INTEGER)
+			}
+		}
+		return nil
+	}
+	items := drain(New("decoded", `0x1f 1_000 2.5e1 "a\tb" 12a 99999999999999999999`, lexLiterals))
+	expected := []interface{}{int64(31), int64(1000), 25.0, "a\tb", nil, nil, nil}
+	for k, item := range items {
+		if item.Value() != expected[k] {
+			t.Errorf("%+v: got value %#v, expected %#v", item, item.Value(), expected[k])
+		}
+	}
+	errs := []string{
+		`strconv.ParseInt: parsing "12a": invalid syntax at 1:25`,
+		`strconv.ParseInt: parsing "99999999999999999999": value out of range at 1:29`,
+	}
+	for k, msg := range errs {
+		if err := items[4+k].Err(); err == nil || err.Error() != msg {
+			t.Errorf("%+v: got error %v, expected %s", items[4+k], err, msg)
+		}
+	}
+	if !errors.Is(items[5].Err(), strconv.ErrRange) {
+		t.Errorf("got error %v, expected it to wrap %v", items[5].Err(), strconv.ErrRange)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "expvar"
+
+// A MetricsSink receives metrics from scanners, so that services can
+// report token throughput and error rates to their monitoring system.
+// Its methods are called on the goroutines of the scanners and must be
+// safe for concurrent use if the sink is shared.
+type MetricsSink interface {
+	// CountItem is called for each item passed to the client.
+	CountItem(t ItemType)
+	// ObserveScan is called when a scan finishes, with its statistics.
+	ObserveScan(st Stats)
+}
+
+// Metrics makes the scanner report to sink. It implies CollectStats.
+func Metrics(sink MetricsSink) Option {
+	return func(s *Scanner) {
+		if s.stats == nil {
+			CollectStats()(s)
+		}
+		s.metrics = sink
+	}
+}
+
+// ExpvarMetrics is a MetricsSink that publishes metrics in an expvar.Map:
+// the number of items of each type, keyed by TypeName, and the totals
+// "scans", "bytes", "errors", "warnings" and "nanoseconds".
+type ExpvarMetrics struct {
+	Map *expvar.Map
+}
+
+// CountItem implements MetricsSink.
+func (m ExpvarMetrics) CountItem(t ItemType) {
+	m.Map.Add(TypeName(t), 1)
+}
+
+// ObserveScan implements MetricsSink.
+func (m ExpvarMetrics) ObserveScan(st Stats) {
+	m.Map.Add("scans", 1)
+	m.Map.Add("bytes", int64(st.Bytes))
+	m.Map.Add("errors", int64(st.Errors))
+	m.Map.Add("warnings", int64(st.Warnings))
+	m.Map.Add("nanoseconds", int64(st.Elapsed))
+}
@@ -0,0 +1,33 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"expvar"
+	"testing"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	m := new(expvar.Map).Init()
+	for i := 0; i < 2; i++ {
+		s := New("metrics", "a !b", lexSpaced, Metrics(ExpvarMetrics{m}))
+		drain(s)
+		s.Stats() // wait for the scan to finish
+	}
+	expect := map[string]int64{
+		TypeName(IDENTIFIER): 4,
+		TypeName(ERROR):      2,
+		"scans":              2,
+		"bytes":              8,
+		"errors":             2,
+		"warnings":           0,
+	}
+	for key, want := range expect {
+		v, ok := m.Get(key).(*expvar.Int)
+		if !ok || v.Value() != want {
+			t.Errorf("%s: got %v expected %d", key, m.Get(key), want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "strings"
+
+// A NewlinePolicy determines which character sequences end a line.
+type NewlinePolicy int
+
+const (
+	// NewlineLF ends lines at "\n" only; a "\r" is an ordinary character.
+	// This is the default.
+	NewlineLF NewlinePolicy = iota
+	// NewlineAny ends lines at "\n", "\r\n" and a lone "\r", as files
+	// written on Windows and classic Mac OS do.
+	NewlineAny
+	// NewlineNormalize is like NewlineAny, but the input and included
+	// text are rewritten before scanning so that every line ends in "\n",
+	// and lexers never see a "\r". Positions refer to the rewritten input.
+	NewlineNormalize
+)
+
+// Newlines sets the newline policy of the scanner, which applies to the
+// lines and columns reported by Position and LineNumber and in error
+// messages, to AddToFileSet and to the end of comments skipped with
+// SkipLineComment.
+func Newlines(p NewlinePolicy) Option {
+	return func(s *Scanner) {
+		s.newlines = p
+		if p == NewlineNormalize {
+			s.input = normalizeNewlines(s.input)
+		}
+	}
+}
+
+var newlineReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// normalizeNewlines replaces each "\r\n" and lone "\r" in text by "\n".
+func normalizeNewlines(text string) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	return newlineReplacer.Replace(text)
+}
+
+// lineOf returns the line number of offset in text, starting at 1, and the
+// offset at which that line starts.
+func (p NewlinePolicy) lineOf(text string, offset int) (line, start int) {
+	if p != NewlineAny {
+		before := text[:offset]
+		return 1 + strings.Count(before, "\n"), strings.LastIndex(before, "\n") + 1
+	}
+	line = 1
+	for i := 0; i < offset; i++ {
+		switch text[i] {
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				continue // the line ends at the '\n'
+			}
+		case '\n':
+		default:
+			continue
+		}
+		line++
+		start = i + 1
+	}
+	return line, start
+}
+
+// prevLine returns the start and the text, without its terminator, of
+// the line ending right before end, which must be the start of a line
+// other than the first.
+func (p NewlinePolicy) prevLine(text string, end int) (start int, line string) {
+	e := end - 1
+	if text[e] == '\n' && e > 0 && text[e-1] == '\r' && p == NewlineAny {
+		e--
+	}
+	if p == NewlineAny {
+		start = strings.LastIndexAny(text[:e], "\r\n") + 1
+	} else {
+		start = strings.LastIndexByte(text[:e], '\n') + 1
+	}
+	return start, text[start:e]
+}
+
+// lineStarts returns the offsets at which the lines of text start.
+func (p NewlinePolicy) lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\n':
+		case text[i] == '\r' && p == NewlineAny && (i+1 == len(text) || text[i+1] != '\n'):
+		default:
+			continue
+		}
+		if i+1 < len(text) {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// lineEnd returns the offset of the first line terminator in text, or -1.
+func (p NewlinePolicy) lineEnd(text string) int {
+	if p == NewlineAny {
+		return strings.IndexAny(text, "\r\n")
+	}
+	return strings.IndexByte(text, '\n')
+}
@@ -0,0 +1,64 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"go/token"
+	"testing"
+)
+
+type newlineTest struct {
+	policy NewlinePolicy
+	offset Pos
+	pos    string
+}
+
+// Offsets in "a\r\nb\rc\nd": a=0, \r=1, \n=2, b=3, \r=4, c=5, \n=6, d=7.
+var newlineTests = []newlineTest{
+	{NewlineLF, 2, "1:3"},
+	{NewlineLF, 3, "2:1"},
+	{NewlineLF, 5, "2:3"},
+	{NewlineLF, 7, "3:1"},
+	{NewlineAny, 1, "1:2"},
+	{NewlineAny, 2, "1:3"},
+	{NewlineAny, 3, "2:1"},
+	{NewlineAny, 5, "3:1"},
+	{NewlineAny, 7, "4:1"},
+	{NewlineNormalize, 2, "2:1"}, // "a\nb\nc\nd"
+	{NewlineNormalize, 4, "3:1"},
+	{NewlineNormalize, 6, "4:1"},
+}
+
+func TestNewlines(t *testing.T) {
+	for _, test := range newlineTests {
+		s := New("", "a\r\nb\rc\nd", nil, Newlines(test.policy))
+		if got := s.Position(test.offset).String(); got != test.pos {
+			t.Errorf("policy %d, offset %d: got %s expected %s", test.policy, test.offset, got, test.pos)
+		}
+		fset := token.NewFileSet()
+		f := s.AddToFileSet(fset)
+		if got := f.Position(test.offset); got.Line != s.Position(test.offset).Line {
+			t.Errorf("policy %d, offset %d: go/token line %d, expected %d", test.policy, test.offset, got.Line, s.Position(test.offset).Line)
+		}
+	}
+}
+
+func TestNewlineLineComment(t *testing.T) {
+	lex := func(s *Scanner) StateFn {
+		s.SkipLineComment("#")
+		s.Emit(COMMENT)
+		return nil
+	}
+	for _, policy := range []NewlinePolicy{NewlineLF, NewlineAny} {
+		items := drain(New("", "# x\r\ny", lex, Newlines(policy), CollectTrivia(SPACE)))
+		want := "# x\r"
+		if policy == NewlineAny {
+			want = "# x"
+		}
+		if got := items[0].LeadingTrivia()[0].Val; got != want {
+			t.Errorf("policy %d: got comment %q expected %q", policy, got, want)
+		}
+	}
+}
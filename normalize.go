@@ -0,0 +1,34 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// NormalizeInput makes the scanner replace its input by f(input) before
+// scanning. It is meant for Unicode normalization, so that visually
+// identical identifiers spelled with different code point sequences
+// compare equal, as most language specifications require; for NFC, pass
+// the String method of norm.NFC from golang.org/x/text/unicode/norm.
+// Positions of items refer to the normalized input.
+func NormalizeInput(f func(string) string) Option {
+	return func(s *Scanner) {
+		s.input = f(s.input)
+	}
+}
+
+// NormalizeItems makes the scanner replace the values of items of the
+// given types, typically identifiers, by their normalization with f before
+// they reach the client. Unlike NormalizeInput, positions and the other
+// items refer to the original input, but the values of normalized items
+// may no longer match it.
+func NormalizeItems(f func(string) string, types ...ItemType) Option {
+	return Map(func(item Item) Item {
+		for _, t := range types {
+			if item.Typ == t {
+				item.Val = f(item.Val)
+				break
+			}
+		}
+		return item
+	})
+}
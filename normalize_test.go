@@ -0,0 +1,57 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+// nfc composes the few sequences used in the tests.
+var nfc = strings.NewReplacer("e\u0301", "é", "a\u0308", "ä").Replace
+
+// lexIdents scans identifiers made of letters and combining marks.
+func lexIdents(s *Scanner) StateFn {
+	w := AllSpace
+	w.Emit, w.Type = true, SPACE
+	switch {
+	case s.SkipSpace(w):
+	case s.Peek() == EOF:
+		s.Emit(EOF)
+		return nil
+	default:
+		for r := s.Next(); r != ' ' && r != EOF; r = s.Next() {
+		}
+		s.Backup()
+		s.Emit(IDENTIFIER)
+	}
+	return lexIdents
+}
+
+func TestNormalizeInput(t *testing.T) {
+	items := drain(New("nfc", "cafe\u0301 café", lexIdents, NormalizeInput(nfc)))
+	expect := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "café"},
+		{Typ: SPACE, Pos: 5, Val: " "},
+		{Typ: IDENTIFIER, Pos: 6, Val: "café"},
+		{Typ: EOF, Pos: 11, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
+
+func TestNormalizeItems(t *testing.T) {
+	items := drain(New("nfc", "cafe\u0301 a\u0308", lexIdents, NormalizeItems(nfc, IDENTIFIER)))
+	expect := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "café"},
+		{Typ: SPACE, Pos: 6, Val: " "},
+		{Typ: IDENTIFIER, Pos: 7, Val: "ä"},
+		{Typ: EOF, Pos: 10, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// An OperatorTable matches the longest operator at the current position
+// of a scanner. It is stored as a trie over the bytes of the operators, so
+// matching costs one step per byte of the matched operator regardless of
+// the size of the table.
+type OperatorTable struct {
+	root opNode
+}
+
+type opNode struct {
+	next     map[byte]*opNode
+	typ      ItemType
+	terminal bool // an operator ends at this node
+}
+
+// NewOperatorTable returns a table containing the operators in m.
+func NewOperatorTable(m map[string]ItemType) *OperatorTable {
+	o := &OperatorTable{}
+	for op, t := range m {
+		n := &o.root
+		for i := 0; i < len(op); i++ {
+			if n.next == nil {
+				n.next = make(map[byte]*opNode)
+			}
+			child := n.next[op[i]]
+			if child == nil {
+				child = &opNode{}
+				n.next[op[i]] = child
+			}
+			n = child
+		}
+		n.typ, n.terminal = t, true
+	}
+	return o
+}
+
+// Match consumes the longest operator at the current position of s and
+// returns its item type. If no operator matches, nothing is consumed and
+// Match reports false.
+func (o *OperatorTable) Match(s *Scanner) (ItemType, bool) {
+	var (
+		typ   ItemType
+		found bool
+		end   Pos
+	)
+	n := &o.root
+	for p := s.pos; int(p) < len(s.input) && n.next != nil; p++ {
+		if n = n.next[s.input[p]]; n == nil {
+			break
+		}
+		if n.terminal {
+			typ, found, end = n.typ, true, p+1
+		}
+	}
+	if found {
+		s.pos = end
+		s.width = 0
+	}
+	return typ, found
+}
@@ -0,0 +1,52 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+const (
+	LT = iota + 200
+	LE
+	SHL
+	SHLASSIGN
+	ASSIGN
+	EQ
+)
+
+var operators = NewOperatorTable(map[string]ItemType{
+	"<":   LT,
+	"<=":  LE,
+	"<<":  SHL,
+	"<<=": SHLASSIGN,
+	"=":   ASSIGN,
+	"==":  EQ,
+})
+
+func TestOperatorMatch(t *testing.T) {
+	tests := []struct {
+		input string
+		typ   ItemType
+		ok    bool
+		rest  string
+	}{
+		{"<<=1", SHLASSIGN, true, "1"},
+		{"<<1", SHL, true, "1"},
+		{"<=", LE, true, ""},
+		{"<", LT, true, ""},
+		{"===", EQ, true, "="},
+		{"!=", 0, false, "!="},
+		{"", 0, false, ""},
+	}
+	for _, test := range tests {
+		s := New(test.input, test.input, nil)
+		typ, ok := operators.Match(s)
+		if typ != test.typ || ok != test.ok {
+			t.Errorf("%q: got %v, %v, expected %v, %v", test.input, typ, ok, test.typ, test.ok)
+		}
+		if rest := s.input[s.pos:]; rest != test.rest {
+			t.Errorf("%q: got rest %q, expected %q", test.input, rest, test.rest)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"runtime"
+	"sync"
+)
+
+// A ChunkFunc returns the positions at which input can be cut into about
+// n chunks that can be scanned independently, in increasing order and
+// strictly between 0 and len(input).
+type ChunkFunc func(input string, n int) []Pos
+
+// LineChunks returns a ChunkFunc cutting line-oriented input, such as logs
+// or CSV, after line feeds. If quote is not 0, a pre-pass skips the line
+// feeds between pairs of quote bytes, such as line breaks in quoted CSV
+// fields; doubled quotes inside quoted text, as in CSV, keep the pairing
+// intact.
+func LineChunks(quote byte) ChunkFunc {
+	return func(input string, n int) []Pos {
+		if n <= 1 {
+			return nil
+		}
+		size := len(input) / n
+		var cuts []Pos
+		quoted := false
+		next := size
+		for i := 0; i < len(input)-1; i++ {
+			switch c := input[i]; {
+			case quote != 0 && c == quote:
+				quoted = !quoted
+			case c == '\n' && !quoted && i+1 >= next:
+				cuts = append(cuts, Pos(i+1))
+				next = i + 1 + size
+			}
+		}
+		return cuts
+	}
+}
+
+// ParallelScan scans input in chunks on up to workers goroutines and
+// returns the items of all chunks in order, up to and including a single
+// EOF item. The chunks are delimited by the positions chunks returns, and
+// each is scanned from state start with the options opts as if the input
+// ended at the end of the chunk, so the lexer must be in state start at
+// the cut positions, as a line-oriented lexer is at the start of a line.
+// Items keep their positions in the whole input. If workers is 0 or
+// negative, GOMAXPROCS goroutines are used.
+func ParallelScan(name, input string, start StateFn, chunks ChunkFunc, workers int, opts ...Option) []Item {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	bounds := append([]Pos{0}, chunks(input, workers)...)
+	bounds = append(bounds, Pos(len(input)))
+	results := make([][]Item, len(bounds)-1)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			from, end := bounds[i], bounds[i+1]
+			s := &Scanner{
+				name:    name,
+				input:   input[:end],
+				state:   start,
+				pos:     from,
+				start:   from,
+				lastPos: from,
+			}
+			s.launch(opts)
+			for {
+				item := s.NextItem()
+				if item.Typ == EOF && i < len(results)-1 {
+					break
+				}
+				results[i] = append(results[i], item)
+				if item.Typ == EOF {
+					break
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	var items []Item
+	for _, r := range results {
+		items = append(items, r...)
+	}
+	return items
+}
@@ -0,0 +1,69 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lexRecords scans lines as IDENTIFIER items. Quoted text may span lines.
+func lexRecords(s *Scanner) StateFn {
+	quoted := false
+	for {
+		switch r := s.Next(); {
+		case r == EOF:
+			if s.Text() != "" {
+				s.Emit(IDENTIFIER)
+			}
+			s.Emit(EOF)
+			return nil
+		case r == '"':
+			quoted = !quoted
+		case r == '\n' && !quoted:
+			s.Backup()
+			s.Emit(IDENTIFIER)
+			s.Next()
+			s.Ignore()
+			return lexRecords
+		}
+	}
+}
+
+func TestLineChunks(t *testing.T) {
+	input := "ab\n\"c\nd\"\ne\nf"
+	tests := []struct {
+		quote byte
+		n     int
+		want  []Pos
+	}{
+		{0, 1, nil},
+		{0, 3, []Pos{6, 11}},
+		{'"', 3, []Pos{9}},
+		{'"', 100, []Pos{3, 9, 11}},
+	}
+	for _, test := range tests {
+		got := LineChunks(test.quote)(input, test.n)
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("quote %q, n %d: got %v, expected %v", test.quote, test.n, got, test.want)
+		}
+	}
+}
+
+func TestParallelScan(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, "line %d \"quoted\n%d\" end\n", i, i)
+	}
+	input := b.String()
+	want := drain(New("sequential", input, lexRecords))
+	for _, workers := range []int{0, 1, 2, 7, 1000} {
+		got := ParallelScan("parallel", input, lexRecords, LineChunks('"'), workers)
+		if !equal(got, want, true) {
+			t.Errorf("%d workers: got %d items\n\t%+v\nexpected %d\n\t%v", workers, len(got), got, len(want), want)
+		}
+	}
+}
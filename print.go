@@ -0,0 +1,89 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Fprint writes items to w, one per line, with their byte offsets, type
+// names as given by TypeName and quoted values:
+//
+//	0        IDENTIFIER   "x"
+//	2        4            "+"
+func Fprint(w io.Writer, items []Item) error {
+	return TypeNames(nil).Fprint(w, items)
+}
+
+// Fprint is like the function Fprint but prints the type names in n.
+func (n TypeNames) Fprint(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	for _, item := range items {
+		printItem(bw, strconv.Itoa(int(item.Pos)), n.Name(item.Typ), item)
+	}
+	return bw.Flush()
+}
+
+// Dump reads the items of s up to EOF and writes them to w as they
+// arrive, in the format of Fprint but with positions given as
+// line:column and type names as given by s.TypeNames. It is meant for
+// debugging lexers.
+func Dump(w io.Writer, s *Scanner) error {
+	for {
+		item := s.NextItem()
+		p := s.Position(item.Pos)
+		if _, err := printItem(w, fmt.Sprintf("%d:%d", p.Line, p.Column), s.TypeNames().Name(item.Typ), item); err != nil {
+			return err
+		}
+		if item.Typ == EOF {
+			return nil
+		}
+	}
+}
+
+func printItem(w io.Writer, pos, typ string, item Item) (int, error) {
+	return fmt.Fprintf(w, "%-8s %-12s %q\n", pos, typ, item.Val)
+}
+
+// Format implements fmt.Formatter. The verbs %v and %s print the item as
+// String does, %q prints its quoted value, %+v adds the type name given by
+// TypeName and the position:
+//
+//	IDENTIFIER "x" at 0
+//
+// and %#v prints the item as a Go composite literal that can be pasted
+// into tests:
+//
+//	scan.Item{Typ: 1, Pos: 0, Val: "x"}
+func (i Item) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprintf(f, "scan.Item{Typ: %s, Pos: %d, Val: %q}", goTypeName(i.Typ), i.Pos, i.Val)
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "%s %q at %d", TypeName(i.Typ), i.Val, i.Pos)
+	case verb == 'v', verb == 's':
+		io.WriteString(f, i.String())
+	case verb == 'q':
+		io.WriteString(f, strconv.Quote(i.Val))
+	default:
+		fmt.Fprintf(f, "%%!%c(scan.Item=%s)", verb, i.String())
+	}
+}
+
+// goTypeName returns t as a Go expression.
+func goTypeName(t ItemType) string {
+	switch t {
+	case EOF:
+		return "scan.EOF"
+	case ERROR:
+		return "scan.ERROR"
+	case WARNING:
+		return "scan.WARNING"
+	}
+	return strconv.Itoa(int(t))
+}
@@ -0,0 +1,132 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFprint(t *testing.T) {
+	var b strings.Builder
+	items := collect(&lexTest{"print", "x +\n12", nil}, "", "")
+	if err := Fprint(&b, items); err != nil {
+		t.Fatal(err)
+	}
+	expected := `0        IDENTIFIER   "x"
+2        4            "+"
+4        INTEGER      "12"
+6        EOF          ""
+`
+	if b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+}
+
+func TestDump(t *testing.T) {
+	var b strings.Builder
+	if err := Dump(&b, New("dump", "x +\n12", lexStart)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `1:1      IDENTIFIER   "x"
+1:3      4            "+"
+2:1      INTEGER      "12"
+2:3      EOF          ""
+`
+	if b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+}
+
+var formatTests = []struct {
+	format string
+	item   Item
+	out    string
+}{
+	{"%v", Item{Typ: IDENTIFIER, Pos: 2, Val: "x"}, `"x"`},
+	{"%s", Item{Typ: INTEGER, Pos: 0, Val: "12345678901"}, `"1234567890"...`},
+	{"%q", Item{Typ: INTEGER, Pos: 0, Val: "12345678901"}, `"12345678901"`},
+	{"%+v", Item{Typ: IDENTIFIER, Pos: 2, Val: "x"}, `IDENTIFIER "x" at 2`},
+	{"%+v", Item{Typ: 4, Pos: 3, Val: "+"}, `4 "+" at 3`},
+	{"%+v", Item{Typ: EOF, Pos: 6}, `EOF "" at 6`},
+	{"%#v", Item{Typ: IDENTIFIER, Pos: 2, Val: "x\n"}, `scan.Item{Typ: 1, Pos: 2, Val: "x\n"}`},
+	{"%#v", Item{Typ: ERROR, Pos: 1, Val: "bad"}, `scan.Item{Typ: scan.ERROR, Pos: 1, Val: "bad"}`},
+	{"%v", Item{Typ: ERROR, Pos: 1, Val: "bad"}, `bad`},
+	{"%d", Item{Typ: IDENTIFIER, Pos: 2, Val: "x"}, `%!d(scan.Item="x")`},
+}
+
+func TestFormat(t *testing.T) {
+	for _, test := range formatTests {
+		out := fmt.Sprintf(test.format, test.item)
+		if out != test.out {
+			t.Errorf("%s: got\n\t%s\nexpected\n\t%s", test.format, out, test.out)
+		}
+	}
+}
+
+func TestSetTypeNames(t *testing.T) {
+	s := New("names", "x +\n12", lexStart)
+	s.SetTypeNames(map[ItemType]string{IDENTIFIER: "Ident", PLUS: "Plus"})
+	var b strings.Builder
+	if err := Dump(&b, s); err != nil {
+		t.Fatal(err)
+	}
+	expected := `1:1      Ident        "x"
+1:3      Plus         "+"
+2:1      INTEGER      "12"
+2:3      EOF          ""
+`
+	if b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+	names := s.TypeNames()
+	item := Item{Typ: PLUS, Pos: 2, Val: "+"}
+	if out := names.String(item); out != `Plus "+"` {
+		t.Errorf("got %s, expected %s", out, `Plus "+"`)
+	}
+	b.Reset()
+	if err := names.WriteJSON(&b, []Item{item}); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); out != "[\n{\"type\":\"Plus\",\"pos\":2,\"val\":\"+\"}\n]\n" {
+		t.Errorf("got JSON %s", out)
+	}
+	items, err := names.ReadJSON(strings.NewReader(b.String()))
+	if err != nil || !reflect.DeepEqual(items, []Item{item}) {
+		t.Errorf("got %v, %v, expected %v", items, err, item)
+	}
+	if _, err := ReadJSON(strings.NewReader(b.String())); err == nil {
+		t.Errorf("decoded an unregistered type name")
+	}
+
+	s = New("names", "(a))", lexStart, TrackBrackets(Bracket{LPAREN, RPAREN}), NamedTypes(map[ItemType]string{RPAREN: "Rparen"}))
+	if err := drain(s)[3]; err.Val != `unmatched Rparen ")" at 1:4` {
+		t.Errorf("got %v, expected the error for an unmatched Rparen", err)
+	}
+}
+
+func TestSetTypeNamesDuplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r != `scan: SetTypeNames: name "Op" given to types 4 and 5` {
+			t.Errorf("got panic %v", r)
+		}
+	}()
+	s := New("names", "", lexStart)
+	s.SetTypeNames(map[ItemType]string{PLUS: "Op", MINUS: "Op"})
+}
+
+func TestTypeNamesType(t *testing.T) {
+	names := TypeNames{PLUS: "Op", MINUS: "Op", LPAREN: "Paren"}
+	for k := 0; k < 10; k++ {
+		if typ, ok := names.Type("Op"); !ok || typ != PLUS {
+			t.Fatalf("got %d, %t, expected %d", typ, ok, PLUS)
+		}
+	}
+	if typ, ok := names.Type("INTEGER"); !ok || typ != INTEGER {
+		t.Errorf("got %d, %t for a registered name", typ, ok)
+	}
+}
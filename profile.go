@@ -0,0 +1,97 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A StateProfiler counts the invocations of state functions and the runes
+// they consume, to help lexer authors find the states worth optimizing:
+//
+//	var p scan.StateProfiler
+//	s := scan.New(name, input, start, scan.ProfileStates(&p))
+//	...
+//	p.WriteReport(os.Stderr)
+//
+// A profiler may be shared by several scanners. The zero value is an empty
+// profile ready to use.
+type StateProfiler struct {
+	mu     sync.Mutex
+	states map[uintptr]*StateProfile
+}
+
+// A StateProfile holds the counters of one state function.
+type StateProfile struct {
+	Name  string // name of the state function, as returned by StateName
+	Calls int    // number of invocations
+	Runes int    // number of runes consumed
+}
+
+// ProfileStates makes the scanner record each invocation of a state
+// function in p.
+func ProfileStates(p *StateProfiler) Option {
+	return func(s *Scanner) {
+		s.profiler = p
+	}
+}
+
+// record records an invocation of fn that moved from position from to
+// position to in input.
+func (p *StateProfiler) record(fn StateFn, from, to Pos, input string) {
+	key := stateKey(fn)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.states == nil {
+		p.states = make(map[uintptr]*StateProfile)
+	}
+	sp := p.states[key]
+	if sp == nil {
+		sp = &StateProfile{Name: StateName(fn)}
+		p.states[key] = sp
+	}
+	sp.Calls++
+	if to > from {
+		sp.Runes += utf8.RuneCountInString(input[from:to])
+	}
+}
+
+// Report returns the profiles of the state functions, hottest first: by
+// runes consumed, then by invocations, then by name.
+func (p *StateProfiler) Report() []StateProfile {
+	p.mu.Lock()
+	report := make([]StateProfile, 0, len(p.states))
+	for _, sp := range p.states {
+		report = append(report, *sp)
+	}
+	p.mu.Unlock()
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i], report[j]
+		if a.Runes != b.Runes {
+			return a.Runes > b.Runes
+		}
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// WriteReport writes the report as a table with one state per line.
+func (p *StateProfiler) WriteReport(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%10s %10s  %s\n", "runes", "calls", "state")
+	for _, sp := range p.Report() {
+		fmt.Fprintf(&b, "%10d %10d  %s\n", sp.Runes, sp.Calls, sp.Name)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
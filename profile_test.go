@@ -0,0 +1,40 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStateProfiler(t *testing.T) {
+	var p StateProfiler
+	s := New("profile", "{1{2}}x", lexBraces, ProfileStates(&p))
+	drain(s)
+	s.NextItem() // wait for the scan to finish
+	expect := []StateProfile{
+		{Name: "scan.lexGroup", Calls: 5, Runes: 5},
+		{Name: "scan.lexBraces", Calls: 3, Runes: 2},
+	}
+	report := p.Report()
+	if len(report) != len(expect) {
+		t.Fatalf("got %v expected %v", report, expect)
+	}
+	for i := range report {
+		if report[i] != expect[i] {
+			t.Errorf("%d: got %v expected %v", i, report[i], expect[i])
+		}
+	}
+	var b strings.Builder
+	if err := p.WriteReport(&b); err != nil {
+		t.Fatal(err)
+	}
+	const table = "     runes      calls  state\n" +
+		"         5          5  scan.lexGroup\n" +
+		"         2          3  scan.lexBraces\n"
+	if b.String() != table {
+		t.Errorf("got report\n%s\nexpected\n%s", b.String(), table)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScanBalanced consumes a region that starts with open at the current
+// position and ends with the matching close, and returns the text between
+// the two. Inside the region, the delimiters given as pairs of opening and
+// closing strings must nest properly; for example,
+//
+//	s.ScanBalanced("${", "}", [2]string{"{", "}"}, [2]string{"(", ")"})
+//
+// consumes "${f(a, {b})}" and returns "f(a, {b})". A closing delimiter that
+// does not match the innermost open one is an error, as is a region that is
+// not closed before the end of the input; the latter error points at the
+// innermost unmatched opening delimiter. If the input does not start with
+// open, ScanBalanced consumes nothing and returns an error.
+func (s *Scanner) ScanBalanced(open, close string, pairs ...[2]string) (string, error) {
+	type opener struct {
+		pos   Pos
+		open  string
+		close string
+	}
+	if !strings.HasPrefix(s.input[s.pos:], open) {
+		return "", fmt.Errorf("expected %q at %s", open, s.lineCol(s.pos))
+	}
+	stack := []opener{{s.pos, open, close}}
+	s.pos += Pos(len(open))
+	s.width = 0
+	inner := s.pos
+	for {
+		rest := s.input[s.pos:]
+		top := stack[len(stack)-1]
+		if strings.HasPrefix(rest, top.close) {
+			s.pos += Pos(len(top.close))
+			if stack = stack[:len(stack)-1]; len(stack) == 0 {
+				return s.input[inner : s.pos-Pos(len(close))], nil
+			}
+			continue
+		}
+		if rest == "" {
+			return "", fmt.Errorf("unmatched %q at %s", top.open, s.lineCol(top.pos))
+		}
+		matched := false
+		for _, p := range pairs {
+			if strings.HasPrefix(rest, p[0]) {
+				stack = append(stack, opener{s.pos, p[0], p[1]})
+				s.pos += Pos(len(p[0]))
+				matched = true
+				break
+			}
+			if strings.HasPrefix(rest, p[1]) {
+				return "", fmt.Errorf("unexpected %q at %s", p[1], s.lineCol(s.pos))
+			}
+		}
+		if !matched {
+			s.Next()
+			s.width = 0
+		}
+	}
+}
+
+// Delimiters describes the delimiters of actions embedded in plain text,
+// such as "{{" and "}}" in text/template, and the item types used for the
+// text and the delimiters. A template lexer alternates between two states:
+//
+//	func lexText(s *scan.Scanner) scan.StateFn {
+//		if delims.ScanText(s) {
+//			return lexAction
+//		}
+//		s.Emit(scan.EOF)
+//		return nil
+//	}
+//
+//	func lexAction(s *scan.Scanner) scan.StateFn {
+//		if delims.ScanRight(s) {
+//			return lexText
+//		}
+//		// Scan the tokens of the action language.
+//	}
+type Delimiters struct {
+	Left, Right string
+	Text        ItemType // type of the plain text between actions
+	LeftDelim   ItemType // type of the left delimiter
+	RightDelim  ItemType // type of the right delimiter
+}
+
+// ScanText consumes plain text up to the next left delimiter and emits it,
+// unless it is empty. It then emits the left delimiter and reports true.
+// If there is no further left delimiter, it consumes the rest of the input
+// and reports false, leaving it to the caller to emit EOF.
+func (d *Delimiters) ScanText(s *Scanner) bool {
+	i := strings.Index(s.input[s.pos:], d.Left)
+	if i < 0 {
+		s.pos = Pos(len(s.input))
+	} else {
+		s.pos += Pos(i)
+	}
+	s.width = 0
+	if s.pos > s.start {
+		s.Emit(d.Text)
+	}
+	if i < 0 {
+		return false
+	}
+	s.pos += Pos(len(d.Left))
+	s.Emit(d.LeftDelim)
+	return true
+}
+
+// ScanRight consumes and emits the right delimiter if the input continues
+// with it, and reports whether it did.
+func (d *Delimiters) ScanRight(s *Scanner) bool {
+	if !strings.HasPrefix(s.input[s.pos:], d.Right) {
+		return false
+	}
+	s.pos += Pos(len(d.Right))
+	s.width = 0
+	s.Emit(d.RightDelim)
+	return true
+}
+
+// ScanAction consumes the text of an action up to the right delimiter
+// without interpreting it, emits it as an item of type t and then emits the
+// right delimiter. It is an alternative to tokenizing actions for languages
+// that treat them as opaque. If the action is not closed, ScanAction emits
+// an error item and reports false.
+func (d *Delimiters) ScanAction(s *Scanner, t ItemType) bool {
+	i := strings.Index(s.input[s.pos:], d.Right)
+	if i < 0 {
+		s.errorAt(s.start, s.unterminated("action"))
+		return false
+	}
+	s.pos += Pos(i)
+	s.width = 0
+	s.Emit(t)
+	return d.ScanRight(s)
+}
@@ -0,0 +1,91 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestScanBalanced(t *testing.T) {
+	tests := []struct {
+		input string
+		text  string
+		err   string
+		rest  string
+	}{
+		{"${a}b", "a", "", "b"},
+		{"${f(a, {b})} c", "f(a, {b})", "", " c"},
+		{"${}", "", "", ""},
+		{"${a {b}", "", `unmatched "${" at 1:1`, ""},
+		{"${a\n {b", "", `unmatched "{" at 2:2`, ""},
+		{"${(a})", "", `unexpected "}" at 1:5`, "})"},
+		{"$a", "", `expected "${" at 1:1`, "$a"},
+	}
+	for _, test := range tests {
+		s := New(test.input, test.input, nil)
+		text, err := s.ScanBalanced("${", "}", [2]string{"{", "}"}, [2]string{"(", ")"})
+		if text != test.text {
+			t.Errorf("%q: got text %q, expected %q", test.input, text, test.text)
+		}
+		if msg := ""; err != nil {
+			msg = err.Error()
+			if msg != test.err {
+				t.Errorf("%q: got error %q, expected %q", test.input, msg, test.err)
+			}
+		} else if test.err != "" {
+			t.Errorf("%q: got no error, expected %q", test.input, test.err)
+		}
+		if rest := s.input[s.pos:]; rest != test.rest {
+			t.Errorf("%q: got rest %q, expected %q", test.input, rest, test.rest)
+		}
+	}
+}
+
+const (
+	TEXT = iota + 600
+	LEFTDELIM
+	RIGHTDELIM
+	ACTION
+)
+
+var delims = Delimiters{Left: "{{", Right: "}}", Text: TEXT, LeftDelim: LEFTDELIM, RightDelim: RIGHTDELIM}
+
+func lexTemplateText(s *Scanner) StateFn {
+	if delims.ScanText(s) {
+		return lexTemplateAction
+	}
+	s.Emit(EOF)
+	return nil
+}
+
+func lexTemplateAction(s *Scanner) StateFn {
+	if !delims.ScanAction(s, ACTION) {
+		return nil
+	}
+	return lexTemplateText
+}
+
+func TestDelimiters(t *testing.T) {
+	tests := []lexTest{
+		{"text", "hello", []Item{{Typ: TEXT, Val: "hello"}, tEOF}},
+		{"actions", "a{{.x}}{{ y }}b", []Item{
+			{Typ: TEXT, Val: "a"},
+			{Typ: LEFTDELIM, Val: "{{"}, {Typ: ACTION, Val: ".x"}, {Typ: RIGHTDELIM, Val: "}}"},
+			{Typ: LEFTDELIM, Val: "{{"}, {Typ: ACTION, Val: " y "}, {Typ: RIGHTDELIM, Val: "}}"},
+			{Typ: TEXT, Val: "b"},
+			tEOF,
+		}},
+		{"unclosed", "a\n{{x", []Item{
+			{Typ: TEXT, Val: "a\n"},
+			{Typ: LEFTDELIM, Val: "{{"},
+			{Typ: ERROR, Val: "unterminated action starting at 2:3"},
+			tEOF,
+		}},
+	}
+	for _, test := range tests {
+		items := drain(New(test.name, test.input, lexTemplateText))
+		if !equal(items, test.items, false) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
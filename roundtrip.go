@@ -0,0 +1,75 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reconstruct reassembles the input from a full-fidelity item stream, as
+// produced by a lexer that emits or collects as trivia every byte of its
+// input. The values of the items are concatenated in order, each preceded
+// by its leading trivia. Error, warning and EOF items are skipped.
+func Reconstruct(items []Item) string {
+	var b strings.Builder
+	for _, item := range items {
+		for _, t := range item.LeadingTrivia() {
+			b.WriteString(t.Val)
+		}
+		switch item.Typ {
+		case ERROR, WARNING, EOF:
+			continue
+		}
+		b.WriteString(item.Val)
+	}
+	return b.String()
+}
+
+// RoundTripError describes where the input reconstructed from an item
+// stream diverges from the original input.
+type RoundTripError struct {
+	Pos  Pos    // offset of the first differing byte
+	Line int    // line number of Pos, starting at 1
+	Col  int    // column of Pos in bytes, starting at 1
+	Got  string // reconstructed text starting at Pos
+	Want string // original input starting at Pos
+}
+
+func (e *RoundTripError) Error() string {
+	return fmt.Sprintf("reconstructed input diverges at %d:%d: got %q, expected %q", e.Line, e.Col, e.Got, e.Want)
+}
+
+// maxRoundTripContext bounds the text shown in a RoundTripError.
+const maxRoundTripContext = 20
+
+// VerifyRoundTrip checks that Reconstruct(items) equals input. Otherwise it
+// returns a *RoundTripError for the first divergence, which catches lexers
+// that silently drop or duplicate text.
+func VerifyRoundTrip(input string, items []Item) error {
+	got := Reconstruct(items)
+	if got == input {
+		return nil
+	}
+	p := 0
+	for p < len(got) && p < len(input) && got[p] == input[p] {
+		p++
+	}
+	lineStart := strings.LastIndex(input[:p], "\n") + 1
+	return &RoundTripError{
+		Pos:  Pos(p),
+		Line: 1 + strings.Count(input[:p], "\n"),
+		Col:  1 + p - lineStart,
+		Got:  truncate(got[p:]),
+		Want: truncate(input[p:]),
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > maxRoundTripContext {
+		return s[:maxRoundTripContext]
+	}
+	return s
+}
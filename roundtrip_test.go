@@ -0,0 +1,47 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestReconstruct(t *testing.T) {
+	const input = "a # one\n%b "
+	items := drain(New("roundtrip", input, lexTrivia, CollectTrivia(SPACE)))
+	if got := Reconstruct(items); got != input {
+		t.Errorf("Reconstruct: got %q expected %q", got, input)
+	}
+	if err := VerifyRoundTrip(input, items); err != nil {
+		t.Errorf("VerifyRoundTrip: %v", err)
+	}
+}
+
+type roundTripTest struct {
+	name  string
+	items []Item
+	err   string
+}
+
+var roundTripTests = []roundTripTest{
+	{"equal", []Item{{Typ: IDENTIFIER, Val: "ab"}, {Typ: ERROR, Val: "oops"}, {Typ: IDENTIFIER, Val: "\ncd"}, {Typ: EOF}}, ""},
+	{"dropped", []Item{{Typ: IDENTIFIER, Val: "ab"}, {Typ: IDENTIFIER, Val: "cd"}},
+		`reconstructed input diverges at 1:3: got "cd", expected "\ncd"`},
+	{"duplicated", []Item{{Typ: IDENTIFIER, Val: "ab"}, {Typ: IDENTIFIER, Val: "\n"}, {Typ: IDENTIFIER, Val: "\ncd"}},
+		`reconstructed input diverges at 2:1: got "\ncd", expected "cd"`},
+	{"truncated", []Item{{Typ: IDENTIFIER, Val: "ab\nc"}},
+		`reconstructed input diverges at 2:2: got "", expected "d"`},
+}
+
+func TestVerifyRoundTrip(t *testing.T) {
+	for _, test := range roundTripTests {
+		err := VerifyRoundTrip("ab\ncd", test.items)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != test.err {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, got, test.err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// Rules is a table-driven lexer. Each rule maps a pattern, given as a
+// Matcher such as Lit, InSet or Regexp, to an action: emit an item, skip
+// the text, or continue with a hand-written state function. At each
+// position the rule with the longest match wins, and among rules matching
+// the same text the one added first:
+//
+//	var rules scan.Rules
+//	rules.Add(scan.Lit("if"), IF).
+//		Add(scan.Regexp(`[a-z]+`), IDENT). // "if" is IF, "iffy" is IDENT
+//		Skip(scan.Repeat(scan.AnyOf(" \t\n"))).
+//		Goto(scan.Lit(`"`), lexString) // lexString returns rules.Lex
+//	s := scan.New(name, input, rules.Lex)
+//
+// The matchers of the rules must not emit or ignore text, so Token and
+// Skip cannot be used in patterns. The zero value is an empty table ready
+// to use. Rules must not be added once scanning has started.
+type Rules struct {
+	rules    []rule
+	matchers []Matcher // the matchers of rules
+}
+
+// A rule is an entry of a Rules table.
+type rule struct {
+	m    Matcher
+	typ  ItemType
+	skip bool    // ignore the matched text instead of emitting it
+	next StateFn // if not nil, continue with next, the matched text pending
+}
+
+// Add adds a rule emitting text matched by m as an item of type t.
+func (r *Rules) Add(m Matcher, t ItemType) *Rules {
+	r.rules = append(r.rules, rule{m: m, typ: t})
+	r.matchers = append(r.matchers, m)
+	return r
+}
+
+// Skip adds a rule ignoring text matched by m, for white space and
+// comments.
+func (r *Rules) Skip(m Matcher) *Rules {
+	r.rules = append(r.rules, rule{m: m, skip: true})
+	r.matchers = append(r.matchers, m)
+	return r
+}
+
+// Goto adds a rule passing control to the state function next after text
+// matched by m, which is left pending. The state function scans the rest
+// of the token, such as the body of a string after its opening quote, and
+// usually returns r.Lex when it is done.
+func (r *Rules) Goto(m Matcher, next StateFn) *Rules {
+	r.rules = append(r.rules, rule{m: m, next: next})
+	r.matchers = append(r.matchers, m)
+	return r
+}
+
+// Lex is the state function applying the rules. At the end of the input it
+// emits EOF. If no rule matches a non-empty text, it emits an error item
+// and terminates the scan.
+func (r *Rules) Lex(s *Scanner) StateFn {
+	if s.Peek() == EOF {
+		s.Emit(EOF)
+		return nil
+	}
+	best := Longest(s, r.matchers...)
+	if best < 0 {
+		s.noMatch()
+		return nil
+	}
+	switch rule := r.rules[best]; {
+	case rule.next != nil:
+		return rule.next
+	case rule.skip:
+		s.Ignore()
+	default:
+		s.Emit(rule.typ)
+	}
+	return r.Lex
+}
+
+// Longest applies each of ms at the current position of s, consumes the
+// longest non-empty match and returns the index of its matcher. Among
+// matchers matching the same text the first wins. If none matches,
+// Longest consumes nothing and returns -1. The matchers must not emit or
+// ignore text.
+func Longest(s *Scanner, ms ...Matcher) int {
+	start := s.Mark()
+	best, end := -1, start
+	for i, m := range ms {
+		if m(s) && s.pos > end.pos {
+			best, end = i, s.Mark()
+		}
+		s.Rewind(start)
+	}
+	s.Rewind(end)
+	return best
+}
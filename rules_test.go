@@ -0,0 +1,88 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"testing"
+)
+
+const KEYWORD = 900
+
+func newTestRules() *Rules {
+	var rules Rules
+	rules.Add(Lit("if"), KEYWORD).
+		Add(Regexp(`[a-z][a-z0-9]*`), IDENTIFIER).
+		Add(Repeat(AnyOf("0123456789")), INTEGER).
+		Add(Lit("+"), PLUS).
+		Add(Lit("++"), MINUS).
+		Skip(Repeat(AnyOf(" \n"))).
+		Goto(Lit("(*"), lexRulesComment(&rules))
+	return &rules
+}
+
+// lexRulesComment emits a comment and returns to the rules.
+func lexRulesComment(rules *Rules) StateFn {
+	return func(s *Scanner) StateFn {
+		for !s.AcceptStringFold("*)") {
+			if s.Next() == EOF {
+				return s.Errorf("unterminated comment")
+			}
+		}
+		s.Emit(COMMENT)
+		return rules.Lex
+	}
+}
+
+type rulesTest struct {
+	name  string
+	input string
+	items []Item
+}
+
+var rulesTests = []rulesTest{
+	{"empty", "", []Item{
+		{Typ: EOF, Pos: 0},
+	}},
+	{"longest match", "if iffy +++ 12x", []Item{
+		{Typ: KEYWORD, Pos: 0, Val: "if"},
+		{Typ: IDENTIFIER, Pos: 3, Val: "iffy"},
+		{Typ: MINUS, Pos: 8, Val: "++"},
+		{Typ: PLUS, Pos: 10, Val: "+"},
+		{Typ: INTEGER, Pos: 12, Val: "12"},
+		{Typ: IDENTIFIER, Pos: 14, Val: "x"},
+		{Typ: EOF, Pos: 15},
+	}},
+	{"goto", "a (* b *)\n1", []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: COMMENT, Pos: 2, Val: "(* b *)"},
+		{Typ: INTEGER, Pos: 10, Val: "1"},
+		{Typ: EOF, Pos: 11},
+	}},
+	{"no match", "a-", []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: ERROR, Pos: 1, Val: "no match for '-' at 1:2"},
+		{Typ: EOF, Pos: 2},
+	}},
+}
+
+func TestRules(t *testing.T) {
+	rules := newTestRules()
+	for _, test := range rulesTests {
+		got := drain(New(test.name, test.input, rules.Lex))
+		if !equal(got, test.items, true) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, got, test.items)
+		}
+	}
+}
+
+func TestLongest(t *testing.T) {
+	s := &Scanner{input: "abc"}
+	if got := Longest(s, Lit("a"), Lit("ab"), Regexp("a.")); got != 1 || s.Text() != "ab" {
+		t.Errorf("got %d, %q, expected 1, \"ab\"", got, s.Text())
+	}
+	if got := Longest(s, Lit("x"), Optional(Lit("x"))); got != -1 || s.Text() != "ab" {
+		t.Errorf("got %d, %q, expected -1, \"ab\"", got, s.Text())
+	}
+}
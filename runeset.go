@@ -0,0 +1,112 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A RuneSet is a precompiled set of runes for AcceptSet and AcceptRunSet.
+// Sets are meant to be built once, typically at package initialization:
+//
+//	var identRunes = NewRuneSet("_").AddRange('a', 'z').AddRange('A', 'Z').AddFunc(unicode.IsLetter)
+//
+// Membership of ASCII runes is computed when the set is built and tested
+// with a bitmap; other runes are tested against the characters, ranges and
+// predicates of the set.
+type RuneSet struct {
+	ascii  asciiSet
+	chars  string // non-ASCII characters
+	ranges [][2]rune
+	funcs  []func(rune) bool
+}
+
+// NewRuneSet returns a rune set containing the characters in chars.
+func NewRuneSet(chars string) *RuneSet {
+	set := &RuneSet{}
+	set.ascii, _ = makeASCIISet(chars)
+	for _, r := range chars {
+		if r >= utf8.RuneSelf {
+			set.chars += string(r)
+		}
+	}
+	return set
+}
+
+// AddRange adds the runes from lo to hi inclusive to the set and returns
+// the set.
+func (set *RuneSet) AddRange(lo, hi rune) *RuneSet {
+	for r := max(lo, 0); r <= hi && r < utf8.RuneSelf; r++ {
+		set.ascii[r/64] |= 1 << (r % 64)
+	}
+	if hi >= utf8.RuneSelf {
+		set.ranges = append(set.ranges, [2]rune{lo, hi})
+	}
+	return set
+}
+
+// AddFunc adds the runes for which f returns true to the set and returns
+// the set. For ASCII runes, f is called only while AddFunc runs.
+func (set *RuneSet) AddFunc(f func(rune) bool) *RuneSet {
+	for r := rune(0); r < utf8.RuneSelf; r++ {
+		if f(r) {
+			set.ascii[r/64] |= 1 << (r % 64)
+		}
+	}
+	set.funcs = append(set.funcs, f)
+	return set
+}
+
+// Contains reports whether r is in the set. EOF is in no set.
+func (set *RuneSet) Contains(r rune) bool {
+	if r < 0 {
+		return false
+	}
+	if r < utf8.RuneSelf {
+		return set.ascii.contains(byte(r))
+	}
+	if strings.ContainsRune(set.chars, r) {
+		return true
+	}
+	for _, rg := range set.ranges {
+		if rg[0] <= r && r <= rg[1] {
+			return true
+		}
+	}
+	for _, f := range set.funcs {
+		if f(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptSet consumes the next rune if it's in set.
+func (s *Scanner) AcceptSet(set *RuneSet) bool {
+	if set.Contains(s.Next()) {
+		return true
+	}
+	s.Backup()
+	return false
+}
+
+// AcceptRunSet consumes a run of runes in set.
+func (s *Scanner) AcceptRunSet(set *RuneSet) {
+	for {
+		if p := int(s.pos); p < len(s.input) && s.input[p] < utf8.RuneSelf {
+			if !set.ascii.contains(s.input[p]) {
+				s.width = 1
+				return
+			}
+			s.pos++
+			continue
+		}
+		if !set.Contains(s.Next()) {
+			s.Backup()
+			return
+		}
+	}
+}
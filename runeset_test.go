@@ -0,0 +1,70 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+var identRunes = NewRuneSet("_").AddRange('a', 'z').AddRange('0', '9').AddFunc(unicode.IsUpper)
+
+type runeSetTest struct {
+	r    rune
+	want bool
+}
+
+var runeSetTests = []runeSetTest{
+	{'_', true},
+	{'a', true},
+	{'z', true},
+	{'5', true},
+	{'A', true},
+	{'Ä', true},
+	{'ä', false},
+	{'-', false},
+	{EOF, false},
+}
+
+func TestRuneSet(t *testing.T) {
+	for _, test := range runeSetTests {
+		if got := identRunes.Contains(test.r); got != test.want {
+			t.Errorf("Contains(%q): got %v expected %v", test.r, got, test.want)
+		}
+	}
+	greek := NewRuneSet("é").AddRange('α', 'ω')
+	for _, r := range "éαβω" {
+		if !greek.Contains(r) {
+			t.Errorf("greek: Contains(%q) is false", r)
+		}
+	}
+	if greek.Contains('a') || greek.Contains('Ω') {
+		t.Errorf("greek contains 'a' or 'Ω'")
+	}
+}
+
+func TestAcceptRunSet(t *testing.T) {
+	s := &Scanner{input: "Äb_9Z-x"}
+	if s.AcceptSet(identRunes) != true || s.Text() != "Ä" {
+		t.Fatalf("AcceptSet: got %q", s.Text())
+	}
+	s.AcceptRunSet(identRunes)
+	if got := s.Text(); got != "Äb_9Z" {
+		t.Errorf("AcceptRunSet: got %q", got)
+	}
+	if s.AcceptSet(identRunes) {
+		t.Errorf("AcceptSet accepted %q", s.Text())
+	}
+}
+
+func BenchmarkAcceptRunSet(b *testing.B) {
+	input := strings.Repeat("z", 4096)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		s := &Scanner{input: input}
+		s.AcceptRunSet(identRunes)
+	}
+}
@@ -7,11 +7,23 @@
 // functions as the next state.
 //
 // See Rob Pike's talk "Lexical Scanning in Go" for an introduction.
+//
+// Item has unexported fields, such as the error of an error item, so
+// composite literals of items must name their fields, as in
+// Item{Typ: EOF, Pos: p}. Unkeyed literals like Item{EOF, p, ""}, which
+// earlier versions of the package allowed, no longer compile.
 package scan
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode/utf8"
 )
 
@@ -22,6 +34,14 @@ type Item struct {
 	Typ ItemType // The type of this item.
 	Pos Pos      // The starting position, in bytes, of this item in the input string.
 	Val string   // The value of this item.
+	err error    // The error carried by an ERROR or WARNING item or of decoding the value, if any.
+	end Pos      // One past the end position of the item's text, or 0 if implied; see End.
+
+	// The trivia preceding the item, behind a pointer to keep items comparable.
+	trivia *[]Item
+
+	// The decoded value of a literal; see EmitInt.
+	value interface{}
 }
 
 // Pos represents a byte position in the original input text.
@@ -31,15 +51,41 @@ func (p Pos) Position() Pos {
 	return p
 }
 
+// Position describes a location in the input in terms of lines and columns.
+type Position struct {
+	Name   string // name of the input or included source
+	Offset int    // byte offset in the source, starting at 0
+	Line   int    // line number, starting at 1
+	Column int    // column number in runes, starting at 1
+}
+
+// String returns the position in the form "name:line:column", or
+// "line:column" if the position has no name.
+func (p Position) String() string {
+	if p.Name == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Name, p.Line, p.Column)
+}
+
 // ItemType identifies the type of scanned items.
 type ItemType int
 
 // Special items used by the package.
 const (
-	ERROR = -2
-	EOF   = -1
+	WARNING = -3
+	ERROR   = -2
+	EOF     = -1
 )
 
+// ErrTooManyErrors is carried by the error item that terminates a scan
+// after the limit set with MaxErrors has been exceeded.
+var ErrTooManyErrors = errors.New("too many errors")
+
+// ErrNoProgress is carried by the error item that terminates a scan whose
+// state functions have stopped making progress; see DetectStalls.
+var ErrNoProgress = errors.New("no progress")
+
 // ItemToString can be defined by the client. It is used in the (Item).String method
 // to print items with types declared by the client.
 var ItemToString func(Item) string
@@ -48,7 +94,7 @@ func (i Item) String() string {
 	switch {
 	case i.Typ == EOF:
 		return "EOF"
-	case i.Typ == ERROR:
+	case i.Typ == ERROR, i.Typ == WARNING:
 		return i.Val
 	case ItemToString != nil:
 		return ItemToString(i)
@@ -58,20 +104,120 @@ func (i Item) String() string {
 	return fmt.Sprintf("%q", i.Val)
 }
 
+// Err returns the error carried by an ERROR or WARNING item, the error of
+// decoding the value of an item emitted by EmitInt, EmitFloat or
+// EmitUnquoted, if any, and nil for all other items.
+// If the item was produced by Errorf with a %w verb, the returned error wraps
+// the original error and can be examined with errors.Is and errors.As.
+func (i Item) Err() error {
+	switch {
+	case i.err != nil:
+		return i.err
+	case i.Typ == ERROR, i.Typ == WARNING:
+		return errors.New(i.Val)
+	}
+	return nil
+}
+
+// End returns the position, in bytes, immediately after the input text
+// of the item. Items emitted by the scanner record their end, so End
+// remains correct for items whose value does not match the input, such as
+// items with values transformed by Map or synthetic items of zero length.
+// For other items End returns Pos plus the length of Val, except for error
+// and warning items, which then end at Pos.
+func (i Item) End() Pos {
+	switch {
+	case i.end > 0:
+		return i.end - 1
+	case i.Typ == ERROR, i.Typ == WARNING:
+		return i.Pos
+	}
+	return i.Pos + Pos(len(i.Val))
+}
+
+// withEnd returns the item with its end set to p. The end is only
+// recorded if End would not report it otherwise, which keeps items
+// comparable to literals that do not set it.
+func (i Item) withEnd(p Pos) Item {
+	if i.End() != p {
+		i.end = p + 1
+	}
+	return i
+}
+
 // StateFn represents the state of the scanner as a function that returns the next state.
 type StateFn func(*Scanner) StateFn
 
 // Scanner holds the state of the scanner.
 type Scanner struct {
-	name       string    // the name of the input; used only for error reports
-	input      string    // the string being scanned
-	state      StateFn   // the next scanning function to enter
-	pos        Pos       // current position in the input
-	start      Pos       // start position of this item
-	width      Pos       // width of last rune read from input
-	lastPos    Pos       // position of most recent item returned by nextItem
-	items      chan Item // channel of scanned items
-	parenDepth int       // nesting depth of ( ) exprs
+	name        string         // the name of the input; used only for error reports
+	input       string         // the string being scanned
+	state       StateFn        // the next scanning function to enter
+	pos         Pos            // current position in the input
+	start       Pos            // start position of this item
+	width       Pos            // width of last rune read from input
+	lastPos     Pos            // position of most recent item returned by nextItem
+	items       chan []Item    // channel of batches of scanned items
+	batch       []Item         // items emitted but not yet sent on the channel
+	received    []Item         // items received from the channel but not yet returned
+	stopped     bool           // the scan was terminated by the package
+	maxErrors   int            // maximum number of error items; 0 means no limit
+	errors      []Item         // error items emitted so far
+	errLimited  bool           // the scan was terminated by MaxErrors
+	emitted     int            // number of items emitted so far
+	maxStalls   int            // maximum transitions without progress; 0 means no limit
+	autoEOF     bool           // emit an EOF item if the state machine ends without one
+	directives  bool           // positions honor line directives in the input
+	bufferSize  int            // capacity of the items channel
+	skipBOM     bool           // skip a leading byte order mark and report others
+	bomSeen     Pos            // end of the last byte order mark reported
+	newlines    NewlinePolicy  // which character sequences end a line
+	bidi        bidiState      // configuration of bidirectional control detection
+	maxInputLen int            // maximum length of the input; 0 means no limit
+	maxTokenLen int            // maximum length of item values; 0 means no limit
+	nesting     int            // nesting depth tracked with EnterNesting
+	maxNesting  int            // maximum nesting depth; 0 means no limit
+	timeBudget  time.Duration  // maximum duration of the scan; 0 means no limit
+	itemBudget  time.Duration  // maximum duration between items; 0 means no limit
+	started     time.Time      // start of the scan, if there is a time budget
+	lastItem    time.Time      // time of the last item sent, if there is a time budget
+	stats       *statsState    // statistics of the scan, if collected
+	metrics     MetricsSink    // receives metrics, if set
+	profiler    *StateProfiler // counts invocations of state functions, if set
+
+	stack        []StateFn                       // states saved by PushState
+	brackets     []Bracket                       // brackets tracked by emit
+	depths       map[ItemType]int                // depths of brackets by kind; see Depth
+	parent       *Scanner                        // scanner running a sub-scan
+	sink         func(Item)                      // receives the items instead of the channel, if set
+	mu           sync.RWMutex                    // guards input and segments against reads from the client
+	segments     []segment                       // sources of the input after Include; nil if there are none
+	onTransition func(from, to StateFn, pos Pos) // called after each state function returns
+	indent       indentation                     // state of the indentation subsystem
+	trivia       triviaState                     // state of trivia collection
+	lossless     bool                            // every byte of the input must be covered by an item
+	covered      Pos                             // end of the input covered by items in lossless mode
+	stage        stage                           // processes items before they reach the client, if set
+	tee          func(Item)                      // receives a copy of the items returned by NextItem, if set
+	lookahead    []Item                          // items peeked or pushed back but not yet returned by NextItem
+	history      []Item                          // most recent items emitted by the lexer, oldest first
+	historySize  int                             // maximum length of history; 0 means 1
+	suspend      *suspendState                   // snapshots for Suspend, if enabled
+	debug        debugState                      // state of the scan for DebugState
+	typeNames    atomic.Pointer[TypeNames]       // names of item types set with SetTypeNames, if any
+}
+
+// Option configures a Scanner. Options are passed to New.
+type Option func(*Scanner)
+
+// MaxErrors limits the number of error items a scan may produce to n.
+// When a further error is emitted, the scanner passes back a final error
+// item carrying ErrTooManyErrors instead and terminates the scan.
+// A limit of 0, the default, means no limit.
+func MaxErrors(n int) Option {
+	return func(s *Scanner) {
+		s.maxErrors = n
+	}
 }
 
 // Next returns the next rune in the input.
@@ -83,9 +229,48 @@ func (s *Scanner) Next() rune {
 	r, w := utf8.DecodeRuneInString(s.input[s.pos:])
 	s.width = Pos(w)
 	s.pos += s.width
+	if r == bom && s.skipBOM {
+		s.unexpectedBOM()
+	}
 	return r
 }
 
+// Read consumes up to len(p) bytes of the input and copies them into p.
+// At the end of the input it returns io.EOF. Read makes Scanner an
+// io.Reader, so that together with ReadRune and UnreadRune the rest of the
+// input can be handed to code such as fmt.Fscan in the middle of a scan.
+func (s *Scanner) Read(p []byte) (n int, err error) {
+	if int(s.pos) >= len(s.input) {
+		return 0, io.EOF
+	}
+	n = copy(p, s.input[s.pos:])
+	s.pos += Pos(n)
+	s.width = 0
+	return n, nil
+}
+
+// ReadRune consumes the next rune in the input and returns it together with
+// its size in bytes. At the end of the input it returns io.EOF. Together
+// with UnreadRune it makes Scanner an io.RuneScanner.
+func (s *Scanner) ReadRune() (r rune, size int, err error) {
+	if r = s.Next(); r == EOF {
+		return 0, 0, io.EOF
+	}
+	return r, int(s.width), nil
+}
+
+// UnreadRune steps back over the rune returned by the last call of
+// ReadRune or Next. Unlike Backup, it returns an error if there is no rune
+// to step back over.
+func (s *Scanner) UnreadRune() error {
+	if s.width == 0 {
+		return errors.New("scan: UnreadRune: previous operation was not a successful ReadRune")
+	}
+	s.Backup()
+	s.width = 0
+	return nil
+}
+
 // Peek returns but does not consume the next rune in the input.
 func (s *Scanner) Peek() rune {
 	r := s.Next()
@@ -98,14 +283,290 @@ func (s *Scanner) Backup() {
 	s.pos -= s.width
 }
 
+// AtEOF reports whether the scanner has consumed all of the input.
+func (s *Scanner) AtEOF() bool {
+	return int(s.pos) >= len(s.input)
+}
+
+// AtLineStart reports whether the next rune is the first of a line, that
+// is whether the scanner is at the start of the input or just after the
+// end of a line as determined by the newline policy. It allows state
+// functions to recognize syntax that is only valid in column 1, such as
+// preprocessor directives or the headers of a diff.
+func (s *Scanner) AtLineStart() bool {
+	if s.pos == 0 {
+		return true
+	}
+	switch s.input[s.pos-1] {
+	case '\n':
+		return true
+	case '\r':
+		return s.newlines == NewlineAny && (int(s.pos) == len(s.input) || s.input[s.pos] != '\n')
+	}
+	return false
+}
+
+// A Checkpoint records the position of a scanner so that it can be
+// restored with Rewind.
+type Checkpoint struct {
+	pos, start, width Pos
+}
+
+// Mark returns a checkpoint for the current position. Together with Rewind
+// it allows speculative scanning: a state function can mark, consume input
+// and rewind if what it found was not what it was looking for.
+func (s *Scanner) Mark() Checkpoint {
+	return Checkpoint{s.pos, s.start, s.width}
+}
+
+// Rewind restores the position recorded in c. Items emitted after c was
+// taken have already been passed to the client and are not taken back.
+func (s *Scanner) Rewind(c Checkpoint) {
+	s.pos, s.start, s.width = c.pos, c.start, c.width
+}
+
+// Seek moves the scanner to position p, which must lie within the input.
+// Pending text is discarded, so the next item starts at p, and the width
+// of the last rune is reset, so a following Backup has no effect. Line
+// numbers are derived from item positions and stay correct. Seek allows a
+// lexer to jump over a precomputed region or to re-scan a span.
+func (s *Scanner) Seek(p Pos) {
+	if p < 0 || int(p) > len(s.input) {
+		panic(fmt.Sprintf("scan: Seek position %d out of range [0, %d]", p, len(s.input)))
+	}
+	s.pos, s.start, s.width = p, p, 0
+}
+
+// PushState saves fn as the state to return to when a sub-lexer shared by
+// several states calls PopState. A state function for strings with
+// interpolation, for example, can be entered from any state:
+//
+//	case '"':
+//		s.PushState(lexExpr)
+//		return lexString
+//
+// and returns to the state it was entered from with
+//
+//	return s.PopState()
+func (s *Scanner) PushState(fn StateFn) {
+	s.stack = append(s.stack, fn)
+}
+
+// PopState removes and returns the state most recently saved by PushState.
+// If no state is saved, it emits an error item and returns nil.
+func (s *Scanner) PopState() StateFn {
+	if len(s.stack) == 0 {
+		return s.Errorf("PopState called with no saved state")
+	}
+	fn := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return fn
+}
+
+// SubScan runs a separate state machine, starting with state start, over
+// the part of the input between the positions from and end, and passes its
+// items on to the client as if they had been emitted by s. The items keep
+// their positions in the whole input and the EOF item of the sub-scan is
+// dropped. SubScan returns when the state machine finishes and leaves the
+// position of s unchanged; the nesting depth of s is the one the
+// sub-scan ends with, and exceeding MaxNesting in the sub-scan ends the
+// scan of s as well. It is meant for embedded regions such as string
+// interpolations, which are usually consumed by s before or after they are
+// sub-scanned.
+func (s *Scanner) SubScan(from, end Pos, start StateFn) {
+	if from < 0 || from > end || int(end) > len(s.input) {
+		panic(fmt.Sprintf("scan: SubScan range [%d, %d] out of range [0, %d]", from, end, len(s.input)))
+	}
+	sub := &Scanner{
+		name:         s.name,
+		input:        s.input[:end],
+		segments:     s.segments,
+		state:        start,
+		pos:          from,
+		start:        from,
+		parent:       s,
+		maxStalls:    s.maxStalls,
+		onTransition: s.onTransition,
+		indent:       s.indent,
+		history:      append([]Item(nil), s.history...),
+		historySize:  s.historySize,
+		skipBOM:      s.skipBOM,
+		bomSeen:      s.bomSeen,
+		newlines:     s.newlines,
+		nesting:      s.nesting,
+		maxNesting:   s.maxNesting,
+		profiler:     s.profiler,
+	}
+	sub.indent.levels = nil
+	sub.sink = func(item Item) {
+		if item.Typ != EOF {
+			s.emit(item)
+		}
+	}
+	sub.runStates()
+	s.nesting = sub.nesting
+}
+
 // Emit passes an item back to the client.
 func (s *Scanner) Emit(t ItemType) {
-	s.items <- Item{t, s.start, s.input[s.start:s.pos]}
+	s.emit(Item{Typ: t, Pos: s.start, Val: s.input[s.start:s.pos]}.withEnd(s.pos))
 	s.start = s.pos
 }
 
+// EmitTrimmed passes an item back to the client like Emit, but with one
+// rune of cutset removed from each end of its value if present, so that
+// delimited tokens such as quoted strings can be passed back without their
+// delimiters. The position and End of the item still span the full text.
+func (s *Scanner) EmitTrimmed(t ItemType, cutset string) {
+	val := s.input[s.start:s.pos]
+	if r, size := utf8.DecodeRuneInString(val); size > 0 && strings.ContainsRune(cutset, r) {
+		val = val[size:]
+	}
+	if r, size := utf8.DecodeLastRuneInString(val); size > 0 && strings.ContainsRune(cutset, r) {
+		val = val[:len(val)-size]
+	}
+	s.emitValue(t, val)
+}
+
+// EmitFunc passes an item back to the client like Emit, but with the
+// value f returns for the pending input, for example a case-folded
+// identifier or an unescaped string. The position and End of the item
+// still span the pending input.
+func (s *Scanner) EmitFunc(t ItemType, f func(raw string) string) {
+	s.emitValue(t, f(s.input[s.start:s.pos]))
+}
+
+// emitValue passes an item of type t with value val and the span of the
+// pending input back to the client.
+func (s *Scanner) emitValue(t ItemType, val string) {
+	s.emit(Item{Typ: t, Pos: s.start, Val: val}.withEnd(s.pos))
+	s.start = s.pos
+}
+
+// emit sends an item to the client.
+func (s *Scanner) emit(item Item) {
+	if s.stopped || s.tokenTooLong(item) {
+		return
+	}
+	if item.Typ != ERROR && item.Typ != WARNING {
+		if s.bidi.enabled && !s.bidiAllowed(item.Typ) {
+			s.checkBidi(item.Pos, item.Val)
+		}
+		s.record(item)
+		if s.brackets != nil {
+			if s.trackBracket(item); s.stopped {
+				return
+			}
+		}
+	}
+	if s.lossless && item.Typ != ERROR && item.Typ != WARNING {
+		s.cover(item)
+	}
+	item, ok := s.process(item)
+	if !ok {
+		return
+	}
+	if len(s.trivia.pending) > 0 && item.Typ != ERROR && item.Typ != WARNING {
+		trivia := s.trivia.pending
+		item.trivia = &trivia
+		s.trivia.pending = nil
+	}
+	if s.sink != nil {
+		s.emitted++
+		s.sink(item)
+		return
+	}
+	if item.Typ == ERROR {
+		if s.maxErrors > 0 && len(s.errors) >= s.maxErrors {
+			item = Item{Typ: ERROR, Pos: item.Pos, Val: ErrTooManyErrors.Error(), err: ErrTooManyErrors, end: item.end}
+			s.stopped, s.errLimited = true, true
+		} else {
+			s.errors = append(s.errors, item)
+		}
+	}
+	s.emitted++
+	if s.stats != nil {
+		s.stats.countItem(item)
+	}
+	if s.metrics != nil {
+		s.metrics.CountItem(item.Typ)
+	}
+	s.batch = append(s.batch, item)
+	s.flush(len(s.batch) >= maxBatch)
+	if s.itemBudget > 0 {
+		s.lastItem = time.Now()
+	}
+}
+
+// maxBatch is the number of emitted items after which the scanner waits
+// for the client to take them.
+const maxBatch = 128
+
+// flush sends the pending batch of items to the client. Unless wait is
+// set, it only does so if the client is ready to receive them, so that
+// items accumulate while the client is busy and are passed on in one
+// synchronization rather than one each.
+func (s *Scanner) flush(wait bool) {
+	if len(s.batch) == 0 {
+		return
+	}
+	if wait {
+		s.items <- s.batch
+	} else {
+		select {
+		case s.items <- s.batch:
+		default:
+			return
+		}
+	}
+	s.batch = nil
+}
+
+// Lossless makes the scanner verify that every byte of the input is
+// covered by exactly one item, in order, as formatters and refactoring
+// tools require. Items must therefore carry the text of the input at
+// their position or, like the items of EmitTrimmed, span it, and white
+// space and comments must be emitted as items or, with the CollectTrivia
+// option, as trivia. Ignoring input without collecting trivia, leaving a
+// gap between items or overlapping items is reported with an error item.
+func Lossless() Option {
+	return func(s *Scanner) {
+		s.lossless = true
+	}
+}
+
+// cover checks that item continues the input covered by the previous
+// items in lossless mode.
+func (s *Scanner) cover(item Item) {
+	end := item.End()
+	switch {
+	case item.Pos > s.covered:
+		s.errorAt(s.covered, fmt.Errorf("input %q not covered by any item at %s", s.input[s.covered:item.Pos], s.lineCol(s.covered)))
+	case item.Pos < s.covered:
+		s.errorAt(item.Pos, fmt.Errorf("item %s overlaps preceding items at %s", s.names().String(item), s.lineCol(item.Pos)))
+	case int(end) > len(s.input) || item.end == 0 && s.input[item.Pos:end] != item.Val:
+		s.errorAt(item.Pos, fmt.Errorf("item %s does not match the input at %s", s.names().String(item), s.lineCol(item.Pos)))
+	}
+	if end > s.covered {
+		s.covered = end
+	}
+}
+
 // Ignore skips over the pending input before this point.
 func (s *Scanner) Ignore() {
+	switch {
+	case s.pos == s.start:
+	case s.trivia.enabled:
+		s.addTrivia(s.trivia.typ)
+	default:
+		if s.bidi.enabled {
+			s.checkBidi(s.start, s.Text())
+		}
+		if s.lossless {
+			s.errorAt(s.start, fmt.Errorf("input %q ignored in lossless mode at %s", s.Text(), s.lineCol(s.start)))
+			s.covered = s.pos
+		}
+	}
 	s.start = s.pos
 }
 
@@ -114,6 +575,46 @@ func (s *Scanner) Text() string {
 	return s.input[s.start:s.pos]
 }
 
+// Pos returns the current position, the offset of the next rune to read.
+// Like the other accessors below, it is meant for helpers called from state
+// functions, such as matchers and debuggers written outside this package.
+func (s *Scanner) Pos() Pos {
+	return s.pos
+}
+
+// StartPos returns the start position of the pending item.
+func (s *Scanner) StartPos() Pos {
+	return s.start
+}
+
+// Width returns the width in bytes of the last rune read by Next, or 0 if
+// it cannot be backed up.
+func (s *Scanner) Width() Pos {
+	return s.width
+}
+
+// Input returns the text being scanned, including any text inserted
+// with Include.
+func (s *Scanner) Input() string {
+	return s.input
+}
+
+// Slice returns the input between start and end, for example the text of
+// a span recorded earlier by an error reporter. Bounds outside the input
+// are clamped to it, and an empty string is returned if end is not after
+// start. Unlike the accessors above, Slice may also be called by the
+// client while the scan is running.
+func (s *Scanner) Slice(start, end Pos) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	start = max(start, 0)
+	end = min(end, Pos(len(s.input)))
+	if end <= start {
+		return ""
+	}
+	return s.input[start:end]
+}
+
 // Accept consumes the next rune if it's from the valid set.
 func (s *Scanner) Accept(valid string) bool {
 	if strings.IndexRune(valid, s.Next()) >= 0 {
@@ -125,47 +626,518 @@ func (s *Scanner) Accept(valid string) bool {
 
 // AcceptRun consumes a run of runes from the valid set.
 func (s *Scanner) AcceptRun(valid string) {
-	for strings.IndexRune(valid, s.Next()) >= 0 {
+	set, asciiOnly := makeASCIISet(valid)
+	for {
+		// Fast path for ASCII input.
+		if p := int(s.pos); p < len(s.input) && s.input[p] < utf8.RuneSelf {
+			if !set.contains(s.input[p]) {
+				s.width = 1
+				return
+			}
+			s.pos++
+			continue
+		}
+		r := s.Next()
+		if r == EOF || asciiOnly || strings.IndexRune(valid, r) < 0 {
+			s.Backup()
+			return
+		}
+	}
+}
+
+// AcceptAny consumes the longest of the alternatives that the input
+// continues with at the current position and returns it. If none matches,
+// nothing is consumed and AcceptAny reports false. For large or fixed sets
+// of operators an OperatorTable is faster.
+func (s *Scanner) AcceptAny(alternatives ...string) (matched string, ok bool) {
+	rest := s.input[s.pos:]
+	for _, alt := range alternatives {
+		if (!ok || len(alt) > len(matched)) && strings.HasPrefix(rest, alt) {
+			matched, ok = alt, true
+		}
+	}
+	if ok {
+		s.pos += Pos(len(matched))
+		s.width = 0
+	}
+	return matched, ok
+}
+
+// asciiSet is a 128-bit set of ASCII characters.
+type asciiSet [2]uint64
+
+// makeASCIISet returns the set of ASCII characters in chars and whether
+// chars consists of ASCII characters only.
+func makeASCIISet(chars string) (set asciiSet, asciiOnly bool) {
+	asciiOnly = true
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if c >= utf8.RuneSelf {
+			asciiOnly = false
+			continue
+		}
+		set[c/64] |= 1 << (c % 64)
+	}
+	return set, asciiOnly
+}
+
+// contains reports whether the ASCII character c is in the set.
+func (set *asciiSet) contains(c byte) bool {
+	return set[c/64]&(1<<(c%64)) != 0
+}
+
+// Recover skips input until one of the synchronization strings is found at
+// the current position or the input is exhausted. The skipped text is
+// ignored and the synchronization string is left to be scanned next. Recover
+// reports whether a synchronization point was found. It is typically called
+// after EmitError to continue scanning after a bad token.
+func (s *Scanner) Recover(sync ...string) bool {
+	for {
+		for _, str := range sync {
+			if strings.HasPrefix(s.input[s.pos:], str) {
+				s.Ignore()
+				return true
+			}
+		}
+		if s.Next() == EOF {
+			s.Ignore()
+			return false
+		}
+	}
+}
+
+// RecoverFunc is like Recover but stops before the first rune satisfying f.
+func (s *Scanner) RecoverFunc(f func(rune) bool) bool {
+	for {
+		r := s.Peek()
+		if r == EOF {
+			s.Ignore()
+			return false
+		}
+		if f(r) {
+			s.Ignore()
+			return true
+		}
+		s.Next()
+	}
+}
+
+// Expect consumes the next rune if it is r. Otherwise it emits an error item
+// of the form "expected 'r', found 'x' at line:column" and reports false,
+// in which case the calling state function usually returns nil.
+func (s *Scanner) Expect(r rune) bool {
+	next := s.Next()
+	if next == r {
+		return true
 	}
 	s.Backup()
+	found := "EOF"
+	if next != EOF {
+		found = strconv.QuoteRune(next)
+	}
+	s.expected(strconv.QuoteRune(r), found)
+	return false
+}
+
+// ExpectString consumes str if the input continues with it. Otherwise it
+// emits an error item like Expect and reports false.
+func (s *Scanner) ExpectString(str string) bool {
+	rest := s.input[s.pos:]
+	if strings.HasPrefix(rest, str) {
+		s.pos += Pos(len(str))
+		s.width = 0
+		return true
+	}
+	found := "EOF"
+	if rest != "" {
+		if len(rest) > len(str) {
+			rest = rest[:len(str)]
+		}
+		found = strconv.Quote(rest)
+	}
+	s.expected(strconv.Quote(str), found)
+	return false
+}
+
+// expected emits an error item describing a mismatch at the current position.
+func (s *Scanner) expected(want, found string) {
+	s.errorAt(s.pos, fmt.Errorf("expected %s, found %s at %s", want, found, s.lineCol(s.pos)))
 }
 
 // LineNumber reports which line we're on, based on the position of
 // the previous Item returned by NextItem. Doing it this way
 // means we don't have to worry about Peek double counting.
 func (s *Scanner) LineNumber() int {
-	return 1 + strings.Count(s.input[:s.lastPos], "\n")
+	return s.Position(s.lastPos).Line
+}
+
+// Position returns the source, line and column of position p in the input.
+// For text inserted with Include, the position refers to the included
+// source. With the LineDirectives option, line directives are honored.
+func (s *Scanner) Position(p Pos) Position {
+	name, text, offset := s.source(p)
+	line, lineStart := s.newlines.lineOf(text, offset)
+	if s.directives {
+		name, line = s.applyLineDirective(text, lineStart, name, line)
+	}
+	return Position{
+		Name:   name,
+		Offset: offset,
+		Line:   line,
+		Column: 1 + utf8.RuneCountInString(text[lineStart:offset]),
+	}
+}
+
+// lineCol returns the line and column of position p in the form used in
+// error messages.
+func (s *Scanner) lineCol(p Pos) string {
+	pos := s.Position(p)
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
 }
 
 // Errorf returns an error item and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating s.NextItem.
+// The format is interpreted as by fmt.Errorf, so an underlying error
+// passed with the %w verb can be retrieved with the item's Err method.
 func (s *Scanner) Errorf(format string, args ...interface{}) StateFn {
-	s.items <- Item{ERROR, s.start, fmt.Sprintf(format, args...)}
+	s.EmitError(format, args...)
 	return nil
 }
 
-// NextItem returns the next item from the input.
+// EmitError passes an error item back to the client without terminating
+// the scan. The state function calling it is free to resynchronize and
+// continue, so clients using EmitError should keep reading items until EOF.
+func (s *Scanner) EmitError(format string, args ...interface{}) {
+	s.errorAt(s.start, fmt.Errorf(format, args...))
+}
+
+// errorAt emits an error item for err at position p.
+func (s *Scanner) errorAt(p Pos, err error) {
+	end := p
+	if s.pos > end {
+		end = s.pos
+	}
+	s.emit(Item{Typ: ERROR, Pos: p, Val: err.Error(), err: err}.withEnd(end))
+}
+
+// Errors returns the error items emitted during the scan, not including
+// the final item reporting that the limit set with MaxErrors was exceeded.
+// It must only be called after NextItem has returned EOF.
+func (s *Scanner) Errors() []Item {
+	return s.errors
+}
+
+// Err returns nil if the scan produced no error items and otherwise an
+// error joining the errors of the items returned by Errors, each prefixed
+// with its position, and ErrTooManyErrors if the limit set with MaxErrors
+// was exceeded. The individual errors can be examined with errors.Is and
+// errors.As. Like Errors, Err must only be called after NextItem has
+// returned EOF:
+//
+//	for item := s.NextItem(); item.Typ != scan.EOF; item = s.NextItem() {
+//		...
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+func (s *Scanner) Err() error {
+	var errs []error
+	for _, item := range s.errors {
+		errs = append(errs, fmt.Errorf("%s: %w", s.Position(item.Pos), item.Err()))
+	}
+	if s.errLimited {
+		errs = append(errs, ErrTooManyErrors)
+	}
+	return errors.Join(errs...)
+}
+
+// EmitWarning passes a warning item back to the client. Warnings report
+// advisory findings such as deprecated syntax; they do not terminate the
+// scan and do not count towards the limit set with MaxErrors.
+func (s *Scanner) EmitWarning(format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+	s.emit(Item{Typ: WARNING, Pos: s.start, Val: err.Error(), err: err}.withEnd(s.pos))
+}
+
+// NextItem returns the next item from the input. Once the state machine
+// has finished, NextItem returns EOF items.
 func (s *Scanner) NextItem() Item {
-	item := <-s.items
+	var item Item
+	if len(s.lookahead) > 0 {
+		item = s.lookahead[0]
+		s.lookahead = s.lookahead[1:]
+	} else {
+		item = s.receive()
+	}
 	s.lastPos = item.Pos
+	if s.suspend != nil {
+		s.suspend.consume(s)
+	}
+	s.debug.setQueued(s)
+	return item
+}
+
+// PeekItem returns the next item from the input without consuming it.
+func (s *Scanner) PeekItem() Item {
+	return s.PeekItemN(0)
+}
+
+// PeekItemN returns the item n places ahead without consuming it;
+// PeekItemN(0) is the item the next call of NextItem returns. Peeked items
+// are buffered until they are consumed.
+func (s *Scanner) PeekItemN(n int) Item {
+	if n < 0 {
+		panic("scan: negative PeekItemN argument")
+	}
+	for len(s.lookahead) <= n {
+		s.lookahead = append(s.lookahead, s.receive())
+	}
+	s.debug.setQueued(s)
+	return s.lookahead[n]
+}
+
+// PushBackItem returns item to the front of the stream, so that it is the
+// next item returned by NextItem and PeekItem, as a parser needs after
+// backtracking from a speculative parse. Items pushed back in turn are
+// returned in the reverse order. Pushed back items are not recorded again
+// by Tee.
+func (s *Scanner) PushBackItem(item Item) {
+	s.lookahead = append(s.lookahead, Item{})
+	copy(s.lookahead[1:], s.lookahead)
+	s.lookahead[0] = item
+	s.debug.setQueued(s)
+}
+
+// TryNextItem is like NextItem but does not block: if the scanner has not
+// produced the next item yet, it returns false immediately, so that event
+// loops can interleave scanning with other work.
+func (s *Scanner) TryNextItem() (Item, bool) {
+	if len(s.lookahead) == 0 && len(s.received) == 0 {
+		select {
+		case batch, ok := <-s.items:
+			s.receiveBatch(batch, ok)
+		default:
+			return Item{}, false
+		}
+	}
+	return s.NextItem(), true
+}
+
+// NextItems fills buf with the next items from the input and returns
+// their number. It blocks until at least one item is available and then
+// adds as many items as are ready without waiting, stopping after an EOF
+// item, so that high-throughput consumers can handle items in batches.
+func (s *Scanner) NextItems(buf []Item) int {
+	if len(buf) == 0 {
+		return 0
+	}
+	buf[0] = s.NextItem()
+	n := 1
+	for n < len(buf) && buf[n-1].Typ != EOF {
+		item, ok := s.TryNextItem()
+		if !ok {
+			break
+		}
+		buf[n] = item
+		n++
+	}
+	return n
+}
+
+// NextItemContext is like NextItem but gives up when ctx is done before
+// the scanner produces the next item, returning the context's error. This
+// is a safety valve for scanning untrusted input that may drive a lexer
+// into a pathological loop. Giving up does not stop the scanner's
+// goroutine; a later call may still receive the item.
+func (s *Scanner) NextItemContext(ctx context.Context) (Item, error) {
+	if len(s.lookahead) == 0 && len(s.received) == 0 {
+		select {
+		case batch, ok := <-s.items:
+			s.receiveBatch(batch, ok)
+		case <-ctx.Done():
+			return Item{}, ctx.Err()
+		}
+	}
+	return s.NextItem(), nil
+}
+
+// NextItemTimeout is like NextItemContext with a context that expires
+// after d.
+func (s *Scanner) NextItemTimeout(d time.Duration) (Item, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return s.NextItemContext(ctx)
+}
+
+// receive returns the next item from the scanner's goroutine.
+func (s *Scanner) receive() Item {
+	if len(s.received) == 0 {
+		batch, ok := <-s.items
+		s.receiveBatch(batch, ok)
+	}
+	item := s.received[0]
+	s.received = s.received[1:]
 	return item
 }
 
+// receiveBatch stores a batch of items received from the channel; ok is
+// false if the channel is closed.
+func (s *Scanner) receiveBatch(batch []Item, ok bool) {
+	if !ok {
+		s.received = []Item{{Typ: EOF, Pos: Pos(len(s.input))}}
+		return
+	}
+	if s.tee != nil {
+		for _, item := range batch {
+			s.tee(item)
+		}
+	}
+	if s.suspend != nil {
+		s.suspend.fetched += len(batch)
+	}
+	s.received = batch
+}
+
+// OnTransition sets a function that is called each time a state function
+// returns, with the state function that ran, the next state and the current
+// position. The final call has a nil next state. The function runs on the
+// scanner's goroutine and is meant for tracing and debugging lexers.
+func OnTransition(f func(from, to StateFn, pos Pos)) Option {
+	return func(s *Scanner) {
+		s.onTransition = f
+	}
+}
+
+// DetectStalls makes the scanner terminate with an error item carrying
+// ErrNoProgress when n consecutive state transitions neither consume input
+// nor emit an item. Such a state machine would otherwise spin forever and
+// block NextItem. A limit of 0, the default, disables the check.
+func DetectStalls(n int) Option {
+	return func(s *Scanner) {
+		s.maxStalls = n
+	}
+}
+
+// AutoEOF makes the scanner emit the EOF item itself when the state
+// machine ends without having emitted one, for example after an error or
+// because a state function forgot to. NextItem returns EOF once the scan
+// has ended in any case, but with AutoEOF the EOF item is emitted like any
+// other, so that it passes through Filter and Map, is counted in the
+// statistics and metrics and reaches a Tee.
+func AutoEOF() Option {
+	return func(s *Scanner) {
+		s.autoEOF = true
+	}
+}
+
+// ItemBuffer sets the capacity of the channel passing batches of items
+// from the scanner's goroutine to the client to n. By default the channel
+// is unbuffered and the lexer only runs ahead of its client while the
+// client is busy, up to a batch of items; a buffer lets the lexer run up to
+// n further batches ahead, which helps pipeline-style consumers.
+func ItemBuffer(n int) Option {
+	return func(s *Scanner) {
+		s.bufferSize = n
+	}
+}
+
+// Debug enables checks that help while developing a lexer. Currently it
+// turns on DetectStalls with a limit of 1000 transitions.
+func Debug() Option {
+	return DetectStalls(1000)
+}
+
 // New creates a new scanner for the input string with initial state start.
-func New(name, input string, start StateFn) *Scanner {
+func New(name, input string, start StateFn, opts ...Option) *Scanner {
 	s := &Scanner{
 		name:  name,
 		input: input,
 		state: start,
-		items: make(chan Item),
 	}
-	go s.run()
+	s.launch(opts)
 	return s
 }
 
+// launch applies the options to a new scanner and starts its goroutine.
+func (s *Scanner) launch(opts []Option) {
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.skipBOM && s.pos == 0 {
+		s.skipLeadingBOM()
+	}
+	if s.suspend != nil {
+		s.suspend.record(s.snapshot())
+	}
+	s.debug.update(s)
+	s.items = make(chan []Item, s.bufferSize)
+	go s.run()
+}
+
 // run runs the state machine for the scanner.
 func (s *Scanner) run() {
-	for s.state != nil {
+	if s.stats != nil {
+		s.stats.started = time.Now()
+	}
+	if s.timeBudget > 0 || s.itemBudget > 0 {
+		s.started = time.Now()
+		s.lastItem = s.started
+	}
+	if !s.checkInputLen() {
+		s.runStates()
+	}
+	if last, ok := s.LastItem(); s.autoEOF && (!ok || last.Typ != EOF) {
+		s.emit(Item{Typ: EOF, Pos: Pos(len(s.input))})
+	}
+	s.debug.finished.Store(true)
+	if s.stats != nil {
+		s.finishStats()
+	}
+	s.flush(true)
+	close(s.items)
+}
+
+// runStates runs state functions until one of them returns nil or the
+// scan is terminated.
+func (s *Scanner) runStates() {
+	stalls := 0
+	for s.state != nil && !s.done() {
+		from, pos, emitted := s.state, s.pos, s.emitted
 		s.state = s.state(s)
+		s.flush(false)
+		s.debug.update(s)
+		if s.suspend != nil {
+			s.suspend.record(s.snapshot())
+		}
+		if s.onTransition != nil {
+			s.onTransition(from, s.state, s.pos)
+		}
+		if s.profiler != nil {
+			s.profiler.record(from, pos, s.pos, s.input)
+		}
+		if s.overBudget() {
+			break
+		}
+		if s.maxStalls <= 0 {
+			continue
+		}
+		if s.pos != pos || s.emitted != emitted {
+			stalls = 0
+			continue
+		}
+		if stalls++; stalls >= s.maxStalls && s.state != nil {
+			s.errorAt(s.pos, fmt.Errorf("%w in state %s after %d transitions", ErrNoProgress, StateName(s.state), stalls))
+			break
+		}
 	}
 }
+
+// done reports whether the scan was terminated by the package.
+func (s *Scanner) done() bool {
+	for ; s != nil; s = s.parent {
+		if s.stopped {
+			return true
+		}
+	}
+	return false
+}
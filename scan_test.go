@@ -5,9 +5,15 @@
 package scan
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 	"unicode"
 )
 
@@ -124,13 +130,13 @@ func lexComment(s *Scanner) StateFn {
 			return lexStart
 		}
 	}
-	return lexStart
 }
 
 // The following tests the lexer above.
 
 // Make the types prettyprint.
 var itemName = map[ItemType]string{
+	WARNING:    "warning",
 	ERROR:      "error",
 	EOF:        "EOF",
 	INTEGER:    "integer",
@@ -156,40 +162,45 @@ type lexTest struct {
 }
 
 var (
-	tEOF    = Item{EOF, 0, ""}
-	tPlus   = Item{PLUS, 0, "+"}
-	tMinus  = Item{MINUS, 0, "-"}
-	tLparen = Item{LPAREN, 0, "("}
-	tRparen = Item{RPAREN, 0, ")"}
+	tEOF    = Item{Typ: EOF, Val: ""}
+	tPlus   = Item{Typ: PLUS, Val: "+"}
+	tMinus  = Item{Typ: MINUS, Val: "-"}
+	tLparen = Item{Typ: LPAREN, Val: "("}
+	tRparen = Item{Typ: RPAREN, Val: ")"}
 )
 
 var lexTests = []lexTest{
 	{"empty", "", []Item{tEOF}},
 	{"3 spaces", "   ", []Item{tEOF}},
 	{"identifiers", `hokus pokus`, []Item{
-		{IDENTIFIER, 0, "hokus"},
-		{IDENTIFIER, 0, "pokus"},
+		{Typ: IDENTIFIER, Val: "hokus"},
+		{Typ: IDENTIFIER, Val: "pokus"},
 		tEOF,
 	}},
 	{"identifiers with comment", `hokus (* first (*) nested *) last *) pokus`, []Item{
-		{IDENTIFIER, 0, "hokus"},
-		{IDENTIFIER, 0, "pokus"},
+		{Typ: IDENTIFIER, Val: "hokus"},
+		{Typ: IDENTIFIER, Val: "pokus"},
+		tEOF,
+	}},
+	{"line break in comment", "a (* b\n *)\nc", []Item{
+		{Typ: IDENTIFIER, Val: "a"},
+		{Typ: IDENTIFIER, Val: "c"},
 		tEOF,
 	}},
 	{"integers", "123 654 990", []Item{
-		{INTEGER, 0, "123"},
-		{INTEGER, 0, "654"},
-		{INTEGER, 0, "990"},
+		{Typ: INTEGER, Val: "123"},
+		{Typ: INTEGER, Val: "654"},
+		{Typ: INTEGER, Val: "990"},
 		tEOF,
 	}},
 	{"expr with integers", "(123 + 654) - 990", []Item{
 		tLparen,
-		{INTEGER, 0, "123"},
+		{Typ: INTEGER, Val: "123"},
 		tPlus,
-		{INTEGER, 0, "654"},
+		{Typ: INTEGER, Val: "654"},
 		tRparen,
 		tMinus,
-		{INTEGER, 0, "990"},
+		{Typ: INTEGER, Val: "990"},
 		tEOF,
 	}},
 }
@@ -207,6 +218,17 @@ func collect(t *lexTest, left, right string) (items []Item) {
 	return
 }
 
+// drain gathers all items up to and including EOF, continuing past errors.
+func drain(s *Scanner) (items []Item) {
+	for {
+		item := s.NextItem()
+		items = append(items, item)
+		if item.Typ == EOF {
+			return
+		}
+	}
+}
+
 func equal(i1, i2 []Item, checkPos bool) bool {
 	if len(i1) != len(i2) {
 		return false
@@ -233,3 +255,890 @@ func TestLex(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorfWrap(t *testing.T) {
+	_, numErr := strconv.Atoi("12x")
+	lexBad := func(s *Scanner) StateFn {
+		return s.Errorf("bad number: %w", numErr)
+	}
+	item := New("wrap", "12x", lexBad).NextItem()
+	if item.Typ != ERROR {
+		t.Fatalf("got %v, expected an error item", item)
+	}
+	if !errors.Is(item.Err(), strconv.ErrSyntax) {
+		t.Errorf("got error %v, expected it to wrap %v", item.Err(), strconv.ErrSyntax)
+	}
+	if item.Val != item.Err().Error() {
+		t.Errorf("got value %q, expected %q", item.Val, item.Err().Error())
+	}
+	if err := (Item{Typ: INTEGER, Val: "1"}).Err(); err != nil {
+		t.Errorf("got error %v for an integer item, expected nil", err)
+	}
+}
+
+func TestEmitError(t *testing.T) {
+	var lexDigits StateFn
+	lexDigits = func(s *Scanner) StateFn {
+		switch r := s.Next(); {
+		case r == EOF:
+			s.Emit(EOF)
+			return nil
+		case unicode.IsDigit(r):
+			s.Emit(INTEGER)
+		default:
+			s.EmitError("unexpected %q", r)
+			s.Ignore()
+		}
+		return lexDigits
+	}
+	test := lexTest{"emit error", "1?2!", []Item{
+		{Typ: INTEGER, Val: "1"},
+		{Typ: ERROR, Val: `unexpected '?'`},
+		{Typ: INTEGER, Val: "2"},
+		{Typ: ERROR, Val: `unexpected '!'`},
+		tEOF,
+	}}
+	items := drain(New(test.name, test.input, lexDigits))
+	if !equal(items, test.items, false) {
+		t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+	}
+}
+
+func TestNextItemAfterError(t *testing.T) {
+	s := New("after error", "?", lexStart)
+	if item := s.NextItem(); item.Typ != ERROR {
+		t.Fatalf("got %v, expected an error item", item)
+	}
+	for i := 0; i < 2; i++ {
+		if item := s.NextItem(); item.Typ != EOF {
+			t.Errorf("got %v after the scan ended, expected EOF", item)
+		}
+	}
+}
+
+// lexWords scans identifiers separated by whitespace. Bad tokens are
+// reported and scanning continues at the next whitespace.
+func lexWords(s *Scanner) StateFn {
+	switch next := s.Peek(); {
+	case next == EOF:
+		s.Emit(EOF)
+		return nil
+	case unicode.IsSpace(next):
+		s.Next()
+		s.Ignore()
+	case unicode.IsLetter(next):
+		for isAlphaNumeric(s.Peek()) {
+			s.Next()
+		}
+		s.Emit(IDENTIFIER)
+	default:
+		s.EmitError("bad token")
+		s.Recover(" ", "\n")
+	}
+	return lexWords
+}
+
+func TestRecover(t *testing.T) {
+	items := drain(New("recover", "a ?? b $\nc", lexWords))
+	expected := []Item{
+		{Typ: IDENTIFIER, Val: "a"},
+		{Typ: ERROR, Val: "bad token"},
+		{Typ: IDENTIFIER, Val: "b"},
+		{Typ: ERROR, Val: "bad token"},
+		{Typ: IDENTIFIER, Val: "c"},
+		tEOF,
+	}
+	if !equal(items, expected, false) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+}
+
+func TestRecoverFunc(t *testing.T) {
+	s := New("recover func", "abc1", nil)
+	if !s.RecoverFunc(unicode.IsDigit) || s.Text() != "" || s.Peek() != '1' {
+		t.Errorf("RecoverFunc did not stop before the digit")
+	}
+	if s.RecoverFunc(unicode.IsSpace) || s.Peek() != EOF {
+		t.Errorf("RecoverFunc did not stop at EOF")
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	s := New("max errors", "a ? b ? ? c ?", lexWords, MaxErrors(2))
+	items := drain(s)
+	expected := []Item{
+		{Typ: IDENTIFIER, Val: "a"},
+		{Typ: ERROR, Val: "bad token"},
+		{Typ: IDENTIFIER, Val: "b"},
+		{Typ: ERROR, Val: "bad token"},
+		{Typ: ERROR, Val: "too many errors"},
+		tEOF,
+	}
+	if !equal(items, expected, false) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+	if !errors.Is(items[4].Err(), ErrTooManyErrors) {
+		t.Errorf("got error %v, expected ErrTooManyErrors", items[4].Err())
+	}
+	errs := s.Errors()
+	if len(errs) != 2 || errs[0].Pos != 2 || errs[1].Pos != 6 {
+		t.Errorf("got errors %+v, expected two errors at 2 and 6", errs)
+	}
+}
+
+func TestAutoEOF(t *testing.T) {
+	lexForgetful := func(s *Scanner) StateFn {
+		s.AcceptRun("ab")
+		s.Emit(IDENTIFIER)
+		return nil
+	}
+	tests := []struct {
+		name  string
+		input string
+		start StateFn
+		opts  []Option
+		teed  []Item
+	}{
+		{"without", "ab?", lexForgetful, nil, []Item{{Typ: IDENTIFIER, Val: "ab"}}},
+		{"forgotten", "ab?", lexForgetful, []Option{AutoEOF()}, []Item{{Typ: IDENTIFIER, Val: "ab"}, {Typ: EOF, Pos: 3}}},
+		{"error", "ab?", lexStart, []Option{AutoEOF()}, []Item{{Typ: IDENTIFIER, Val: "ab"}, {Typ: ERROR, Pos: 2, Val: "lex error"}, {Typ: EOF, Pos: 3}}},
+		{"emitted", "ab ", lexStart, []Option{AutoEOF()}, []Item{{Typ: IDENTIFIER, Val: "ab"}, {Typ: EOF, Pos: 3}}},
+	}
+	for _, test := range tests {
+		var teed []Item
+		items := drain(New(test.name, test.input, test.start, append(test.opts, Tee(func(item Item) {
+			teed = append(teed, item)
+		}))...))
+		if last := items[len(items)-1]; last.Typ != EOF || last.Pos != 3 {
+			t.Errorf("%s: got last item %+v, expected EOF at 3", test.name, last)
+		}
+		if !equal(teed, test.teed, true) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, teed, test.teed)
+		}
+	}
+}
+
+func TestErr(t *testing.T) {
+	s := New("err", "a b", lexWords)
+	drain(s)
+	if err := s.Err(); err != nil {
+		t.Errorf("got error %v for a clean scan, expected nil", err)
+	}
+	s = New("err", "a ?\nb ? ? c ?", lexWords, MaxErrors(2))
+	drain(s)
+	err := s.Err()
+	expected := "err:1:3: bad token\nerr:2:3: bad token\ntoo many errors"
+	if err == nil || err.Error() != expected {
+		t.Errorf("got error\n\t%v\nexpected\n\t%s", err, expected)
+	}
+	if !errors.Is(err, ErrTooManyErrors) {
+		t.Errorf("got error %v, expected it to wrap ErrTooManyErrors", err)
+	}
+}
+
+func TestEmitWarning(t *testing.T) {
+	var lexTabs StateFn
+	lexTabs = func(s *Scanner) StateFn {
+		switch s.Next() {
+		case EOF:
+			s.Emit(EOF)
+			return nil
+		case '\t':
+			s.EmitWarning("tab at offset %d", s.start)
+		}
+		s.Ignore()
+		return lexTabs
+	}
+	s := New("warnings", "a\tb\t", lexTabs, MaxErrors(1))
+	items := drain(s)
+	expected := []Item{
+		{Typ: WARNING, Val: "tab at offset 1"},
+		{Typ: WARNING, Val: "tab at offset 3"},
+		tEOF,
+	}
+	if !equal(items, expected, false) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+	if items[0].Err() == nil {
+		t.Errorf("warning carries no error")
+	}
+	if len(s.Errors()) != 0 {
+		t.Errorf("got errors %v, expected none", s.Errors())
+	}
+}
+
+func TestOnTransition(t *testing.T) {
+	var calls int
+	var last StateFn = lexStart
+	var lastPos Pos
+	trace := func(from, to StateFn, pos Pos) {
+		calls++
+		last, lastPos = to, pos
+	}
+	s := New("transitions", "ab 12", lexStart, OnTransition(trace))
+	drain(s)
+	s.NextItem() // wait for the state machine to finish
+	// lexStart, lexIdentifier, lexStart, lexSpace, lexStart, lexInteger, lexStart
+	if calls != 7 {
+		t.Errorf("got %d transitions, expected 7", calls)
+	}
+	if last != nil || lastPos != 5 {
+		t.Errorf("final transition did not end the scan at the end of input")
+	}
+}
+
+func TestDetectStalls(t *testing.T) {
+	var lexStuck StateFn
+	lexStuck = func(s *Scanner) StateFn {
+		if s.Peek() == 'a' {
+			s.Next()
+			s.Emit(IDENTIFIER)
+		}
+		return lexStuck // never gives up at EOF
+	}
+	items := drain(New("stuck", "a", lexStuck, Debug()))
+	if len(items) != 3 || items[1].Typ != ERROR {
+		t.Fatalf("got %v, expected an identifier followed by an error", items)
+	}
+	if !errors.Is(items[1].Err(), ErrNoProgress) {
+		t.Errorf("got error %v, expected ErrNoProgress", items[1].Err())
+	}
+	if !strings.Contains(items[1].Val, "after 1000 transitions") {
+		t.Errorf("got %q, expected the number of transitions", items[1].Val)
+	}
+}
+
+func TestAtLineStart(t *testing.T) {
+	const input = "a\nb\r\nc\rd"
+	for _, test := range []struct {
+		policy NewlinePolicy
+		starts string
+	}{
+		{NewlineLF, "yny" + "nny" + "nnn"},
+		{NewlineAny, "yny" + "nny" + "nyn"},
+	} {
+		s := New("lines", input, nil, Newlines(test.policy))
+		var starts []byte
+		for p := 0; p <= len(input); p++ {
+			s.pos = Pos(p)
+			if s.AtLineStart() {
+				starts = append(starts, 'y')
+			} else {
+				starts = append(starts, 'n')
+			}
+			if s.AtEOF() != (p == len(input)) {
+				t.Errorf("%d: got AtEOF %t at offset %d", test.policy, s.AtEOF(), p)
+			}
+		}
+		if string(starts) != test.starts {
+			t.Errorf("%d: got\n\t%s\nexpected\n\t%s", test.policy, starts, test.starts)
+		}
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	var got string
+	lexAccess := func(s *Scanner) StateFn {
+		s.AcceptRun("a")
+		s.Ignore()
+		s.Next()
+		got = fmt.Sprintf("%d %d %d %s", s.StartPos(), s.Pos(), s.Width(), s.Input())
+		return nil
+	}
+	drain(New("access", "aaé!", lexAccess))
+	if expected := "2 4 2 aaé!"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestSlice(t *testing.T) {
+	s := New("slice", "abc def", lexStart)
+	drain(s)
+	for _, test := range []struct {
+		start, end Pos
+		text       string
+	}{
+		{0, 3, "abc"},
+		{4, 7, "def"},
+		{-2, 2, "ab"},
+		{5, 100, "ef"},
+		{3, 3, ""},
+		{5, 2, ""},
+		{8, 9, ""},
+	} {
+		if text := s.Slice(test.start, test.end); text != test.text {
+			t.Errorf("Slice(%d, %d): got %q, expected %q", test.start, test.end, text, test.text)
+		}
+	}
+}
+
+func TestAcceptAny(t *testing.T) {
+	ops := []string{"<", "<=", "<<", "<<="}
+	for _, test := range []struct {
+		input   string
+		matched string
+		ok      bool
+	}{
+		{"<<=1", "<<=", true},
+		{"<<1", "<<", true},
+		{"<=<", "<=", true},
+		{"<", "<", true},
+		{"=<", "", false},
+		{"", "", false},
+	} {
+		s := New("any", test.input, nil)
+		matched, ok := s.AcceptAny(ops...)
+		if matched != test.matched || ok != test.ok || s.Text() != test.matched {
+			t.Errorf("%q: got %q, %t with pending %q, expected %q, %t", test.input, matched, ok, s.Text(), test.matched, test.ok)
+		}
+	}
+}
+
+func TestEmitTrimmed(t *testing.T) {
+	lexQuoted := func(s *Scanner) StateFn {
+		for s.Peek() != EOF {
+			s.Next()
+			if s.SkipUntil(`"`) {
+				s.Next()
+			}
+			s.EmitTrimmed(IDENTIFIER, `"`)
+		}
+		s.Emit(EOF)
+		return nil
+	}
+	items := drain(New("trimmed", `"a b""""x`, lexQuoted, Lossless()))
+	expected := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a b"},
+		{Typ: IDENTIFIER, Pos: 5, Val: ""},
+		{Typ: IDENTIFIER, Pos: 7, Val: "x"},
+		{Typ: EOF, Pos: 9},
+	}
+	if !equal(items, expected, true) {
+		t.Fatalf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+	for k, end := range []Pos{5, 7, 9} {
+		if items[k].End() != end {
+			t.Errorf("%+v: got end %d, expected %d", items[k], items[k].End(), end)
+		}
+	}
+}
+
+func TestEmitFunc(t *testing.T) {
+	lexFolded := func(s *Scanner) StateFn {
+		s.AcceptRun("ABCabc")
+		s.EmitFunc(IDENTIFIER, strings.ToLower)
+		s.AcceptRun("ß")
+		s.EmitFunc(IDENTIFIER, func(raw string) string {
+			return strings.ReplaceAll(raw, "ß", "ss")
+		})
+		return nil
+	}
+	items := drain(New("func", "AbCßß", lexFolded))
+	expected := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "abc"},
+		{Typ: IDENTIFIER, Pos: 3, Val: "ssss"},
+		{Typ: EOF, Pos: 7},
+	}
+	if !equal(items, expected, true) {
+		t.Fatalf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+	if items[1].End() != 7 {
+		t.Errorf("%+v: got end %d, expected 7", items[1], items[1].End())
+	}
+}
+
+func TestMarkRewind(t *testing.T) {
+	// lexNumber scans "1.5" as a single item but "1.x" as an integer
+	// followed by a selector.
+	var lexNumber StateFn
+	lexNumber = func(s *Scanner) StateFn {
+		switch r := s.Peek(); {
+		case r == EOF:
+			s.Emit(EOF)
+			return nil
+		case unicode.IsDigit(r):
+			s.AcceptRun("0123456789")
+			c := s.Mark()
+			if s.Accept(".") && s.Accept("0123456789") {
+				s.AcceptRun("0123456789")
+			} else {
+				s.Rewind(c)
+			}
+			s.Emit(INTEGER)
+		case r == '.':
+			s.Next()
+			s.Emit(PLUS)
+		case r == ' ':
+			s.Next()
+			s.Ignore()
+		default:
+			s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+			s.Emit(IDENTIFIER)
+		}
+		return lexNumber
+	}
+	items := drain(New("mark", "1.5 1.x", lexNumber))
+	expected := []Item{
+		{Typ: INTEGER, Val: "1.5"},
+		{Typ: INTEGER, Val: "1"},
+		{Typ: PLUS, Val: "."},
+		{Typ: IDENTIFIER, Val: "x"},
+		tEOF,
+	}
+	if !equal(items, expected, false) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	s := New("seek", "abc def", nil)
+	s.Next()
+	s.Seek(4)
+	if s.Text() != "" {
+		t.Errorf("got pending text %q after Seek, expected none", s.Text())
+	}
+	s.Backup()
+	if r := s.Next(); r != 'd' {
+		t.Errorf("got %q after Seek, expected 'd'", r)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Seek beyond the input did not panic")
+		}
+	}()
+	s.Seek(8)
+}
+
+func TestExpect(t *testing.T) {
+	lexAssign := func(s *Scanner) StateFn {
+		s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		s.Emit(IDENTIFIER)
+		if !s.ExpectString(":=") {
+			return nil
+		}
+		s.Ignore()
+		if !s.Expect('1') {
+			return nil
+		}
+		s.Emit(INTEGER)
+		if !s.Expect(';') {
+			return nil
+		}
+		s.Ignore()
+		s.Emit(EOF)
+		return nil
+	}
+	tests := []lexTest{
+		{"ok", "x:=1;", []Item{{Typ: IDENTIFIER, Val: "x"}, {Typ: INTEGER, Val: "1"}, tEOF}},
+		{"string", "x\n=1;", []Item{{Typ: IDENTIFIER, Val: "x"}, {Typ: ERROR, Val: `expected ":=", found "\n=" at 1:2`}, tEOF}},
+		{"rune", "abc:=ä1;", []Item{{Typ: IDENTIFIER, Val: "abc"}, {Typ: ERROR, Val: `expected '1', found 'ä' at 1:6`}, tEOF}},
+		{"eof", "x:=1", []Item{{Typ: IDENTIFIER, Val: "x"}, {Typ: INTEGER, Val: "1"}, {Typ: ERROR, Val: `expected ';', found EOF at 1:5`}, tEOF}},
+	}
+	for _, test := range tests {
+		items := drain(New(test.name, test.input, lexAssign))
+		if !equal(items, test.items, false) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	s := New("pos.txt", "ab\ncäd", nil)
+	p := s.Position(6)
+	if p.String() != "pos.txt:2:3" || p.Offset != 6 {
+		t.Errorf("got %v at offset %d, expected pos.txt:2:3 at offset 6", p, p.Offset)
+	}
+}
+
+func TestItemEnd(t *testing.T) {
+	lexWords := func(s *Scanner) StateFn {
+		s.AcceptRun("abc")
+		s.Emit(IDENTIFIER)
+		s.Emit(PLUS)
+		s.AcceptRun(" ")
+		s.Ignore()
+		s.AcceptRun("123")
+		return s.Errorf("unexpected %q", s.Text())
+	}
+	short := Map(func(item Item) Item {
+		if item.Typ == IDENTIFIER {
+			item.Val = "x"
+		}
+		return item
+	})
+	items := drain(New("end", "abc 12", lexWords, short))
+	expected := []Pos{3, 3, 6, 6}
+	var ends []Pos
+	for _, item := range items {
+		ends = append(ends, item.End())
+	}
+	if fmt.Sprint(ends) != fmt.Sprint(expected) {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", ends, expected)
+	}
+	for _, test := range []struct {
+		item Item
+		end  Pos
+	}{
+		{Item{Typ: IDENTIFIER, Pos: 2, Val: "abc"}, 5},
+		{Item{Typ: ERROR, Pos: 2, Val: "bad"}, 2},
+		{Item{Typ: EOF, Pos: 7}, 7},
+	} {
+		if end := test.item.End(); end != test.end {
+			t.Errorf("%+v: got end %d, expected %d", test.item, end, test.end)
+		}
+	}
+}
+
+// lexBraces scans identifiers and brace-delimited groups of them, which
+// may nest. Groups share the state lexGroup, which returns to the state
+// it was entered from.
+func lexBraces(s *Scanner) StateFn {
+	switch r := s.Next(); {
+	case r == EOF:
+		s.Emit(EOF)
+		return nil
+	case r == '{':
+		s.Emit(LPAREN)
+		s.PushState(lexBraces)
+		return lexGroup
+	case r == '}':
+		s.Emit(RPAREN)
+		return s.PopState()
+	}
+	s.Emit(IDENTIFIER)
+	return lexBraces
+}
+
+func lexGroup(s *Scanner) StateFn {
+	switch r := s.Next(); {
+	case r == '{':
+		s.Emit(LPAREN)
+		s.PushState(lexGroup)
+		return lexGroup
+	case r == '}':
+		s.Emit(RPAREN)
+		return s.PopState()
+	case r == EOF:
+		return s.Errorf("unclosed group")
+	}
+	s.Emit(INTEGER)
+	return lexGroup
+}
+
+func TestPushPopState(t *testing.T) {
+	items := drain(New("modes", "a{b{c}d}e}", lexBraces))
+	expected := []Item{
+		{Typ: IDENTIFIER, Val: "a"},
+		{Typ: LPAREN, Val: "{"}, {Typ: INTEGER, Val: "b"},
+		{Typ: LPAREN, Val: "{"}, {Typ: INTEGER, Val: "c"}, {Typ: RPAREN, Val: "}"},
+		{Typ: INTEGER, Val: "d"}, {Typ: RPAREN, Val: "}"},
+		{Typ: IDENTIFIER, Val: "e"},
+		{Typ: RPAREN, Val: "}"},
+		{Typ: ERROR, Val: "PopState called with no saved state"},
+		tEOF,
+	}
+	if !equal(items, expected, false) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+}
+
+// lexInterpolated scans strings in double quotes. Expressions in ${...}
+// inside the strings are scanned with lexStart.
+func lexInterpolated(s *Scanner) StateFn {
+	switch s.Next() {
+	case EOF:
+		s.Emit(EOF)
+		return nil
+	case '"':
+		s.Ignore()
+		return lexStringPart
+	}
+	return s.Errorf("expected string")
+}
+
+func lexStringPart(s *Scanner) StateFn {
+	for {
+		if strings.HasPrefix(s.input[s.pos:], "${") {
+			s.Emit(IDENTIFIER)
+			inner, err := s.ScanBalanced("${", "}")
+			if err != nil {
+				return s.Errorf("%v", err)
+			}
+			end := s.pos - 1
+			s.SubScan(end-Pos(len(inner)), end, lexStart)
+			s.Ignore()
+		}
+		switch s.Next() {
+		case EOF:
+			return s.Errorf("unterminated string")
+		case '"':
+			s.Backup()
+			s.Emit(IDENTIFIER)
+			s.Next()
+			s.Ignore()
+			return lexInterpolated
+		}
+	}
+}
+
+func TestSubScan(t *testing.T) {
+	items := drain(New("interpolation", `"a ${(1 + x)} b${y}"`, lexInterpolated))
+	expected := []Item{
+		{Typ: IDENTIFIER, Pos: 1, Val: "a "},
+		{Typ: LPAREN, Pos: 5, Val: "("},
+		{Typ: INTEGER, Pos: 6, Val: "1"},
+		{Typ: PLUS, Pos: 8, Val: "+"},
+		{Typ: IDENTIFIER, Pos: 10, Val: "x"},
+		{Typ: RPAREN, Pos: 11, Val: ")"},
+		{Typ: IDENTIFIER, Pos: 13, Val: " b"},
+		{Typ: IDENTIFIER, Pos: 17, Val: "y"},
+		{Typ: IDENTIFIER, Pos: 19, Val: ""},
+		{Typ: EOF, Pos: 20},
+	}
+	if !equal(items, expected, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", items, expected)
+	}
+}
+
+var _ io.RuneScanner = (*Scanner)(nil)
+
+func TestRuneScanner(t *testing.T) {
+	// lexFscan hands the input to fmt.Fscan to read integers.
+	var sum int
+	lexFscan := func(s *Scanner) StateFn {
+		for {
+			var n int
+			if _, err := fmt.Fscan(s, &n); err != nil {
+				break
+			}
+			sum += n
+		}
+		s.Ignore()
+		s.Emit(EOF)
+		return nil
+	}
+	items := drain(New("fscan", "1 22\n333", lexFscan))
+	if len(items) != 1 || sum != 356 {
+		t.Errorf("got %v and sum %d, expected EOF and 356", items, sum)
+	}
+
+	s := New("runes", "ä", nil)
+	if err := s.UnreadRune(); err == nil {
+		t.Errorf("UnreadRune before ReadRune succeeded")
+	}
+	if r, size, err := s.ReadRune(); r != 'ä' || size != 2 || err != nil {
+		t.Errorf("got %q, %d, %v, expected 'ä', 2, nil", r, size, err)
+	}
+	if err := s.UnreadRune(); err != nil {
+		t.Errorf("UnreadRune failed: %v", err)
+	}
+	if err := s.UnreadRune(); err == nil {
+		t.Errorf("second UnreadRune succeeded")
+	}
+	s.ReadRune()
+	if _, _, err := s.ReadRune(); err != io.EOF {
+		t.Errorf("got %v at the end of input, expected io.EOF", err)
+	}
+}
+
+func TestPeekItem(t *testing.T) {
+	s := New("peek", "a b", lexSpaced, Filter(notType(SPACE)))
+	if item := s.PeekItemN(1); item.Val != "b" {
+		t.Errorf("PeekItemN(1): got %v", item)
+	}
+	if item := s.PeekItem(); item.Val != "a" {
+		t.Errorf("PeekItem: got %v", item)
+	}
+	if item := s.NextItem(); item.Val != "a" {
+		t.Errorf("NextItem: got %v", item)
+	}
+	if item := s.PeekItemN(3); item.Typ != EOF || item.Pos != 3 {
+		t.Errorf("PeekItemN(3): got %v", item)
+	}
+	rest := drain(s)
+	expect := []Item{
+		{Typ: IDENTIFIER, Pos: 2, Val: "b"},
+		{Typ: EOF, Pos: 3, Val: ""},
+	}
+	if !equal(rest, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", rest, expect)
+	}
+}
+
+func TestPushBackItem(t *testing.T) {
+	s := New("pushback", "a b", lexSpaced, Filter(notType(SPACE)))
+	a := s.NextItem()
+	b := s.NextItem()
+	if s.PeekItem().Typ != EOF {
+		t.Fatalf("PeekItem: expected EOF")
+	}
+	s.PushBackItem(b)
+	s.PushBackItem(a)
+	if item := s.PeekItemN(1); item != b {
+		t.Errorf("PeekItemN(1): got %v expected %v", item, b)
+	}
+	items := drain(s)
+	expect := []Item{a, b, {Typ: EOF, Pos: 3, Val: ""}}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
+
+func TestTryNextItem(t *testing.T) {
+	release := make(chan bool)
+	lex := func(s *Scanner) StateFn {
+		<-release
+		s.Next()
+		s.Emit(IDENTIFIER)
+		return nil
+	}
+	s := New("try", "a", lex)
+	if item, ok := s.TryNextItem(); ok {
+		t.Errorf("TryNextItem before scanning: got %v", item)
+	}
+	close(release)
+	var items []Item
+	for len(items) < 2 {
+		if item, ok := s.TryNextItem(); ok {
+			items = append(items, item)
+		} else {
+			runtime.Gosched()
+		}
+	}
+	expect := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: EOF, Pos: 1, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
+
+func TestNextItemTimeout(t *testing.T) {
+	release := make(chan bool)
+	lex := func(s *Scanner) StateFn {
+		<-release
+		s.Next()
+		s.Emit(IDENTIFIER)
+		return nil
+	}
+	s := New("timeout", "a", lex)
+	if item, err := s.NextItemTimeout(time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("NextItemTimeout: got %v, %v", item, err)
+	}
+	close(release)
+	item, err := s.NextItemContext(context.Background())
+	if err != nil || item.Typ != IDENTIFIER {
+		t.Errorf("NextItemContext: got %v, %v", item, err)
+	}
+}
+
+func TestNextItems(t *testing.T) {
+	s := New("batch", "a b c", lexSpaced, Filter(notType(SPACE)))
+	var items []Item
+	buf := make([]Item, 2)
+	for len(items) == 0 || items[len(items)-1].Typ != EOF {
+		n := s.NextItems(buf)
+		if n < 1 || n > len(buf) {
+			t.Fatalf("NextItems returned %d", n)
+		}
+		items = append(items, buf[:n]...)
+	}
+	expect := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: IDENTIFIER, Pos: 2, Val: "b"},
+		{Typ: IDENTIFIER, Pos: 4, Val: "c"},
+		{Typ: EOF, Pos: 5, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+	if n := s.NextItems(nil); n != 0 {
+		t.Errorf("NextItems(nil) returned %d", n)
+	}
+}
+
+func TestItemBuffer(t *testing.T) {
+	done := make(chan bool)
+	lex := func(s *Scanner) StateFn {
+		for s.Next() != EOF {
+			s.Emit(IDENTIFIER)
+		}
+		close(done)
+		return nil
+	}
+	s := New("buffer", "abc", lex, ItemBuffer(3))
+	<-done // the lexer ran ahead without a client
+	buf := make([]Item, 5)
+	// The EOF item is ready too if the scanner has closed the channel.
+	if n := s.NextItems(buf); n < 3 || buf[2].Val != "c" {
+		t.Errorf("NextItems: got %d items %v, expected at least 3", n, buf[:n])
+	}
+}
+
+func TestBatching(t *testing.T) {
+	done := make(chan bool)
+	lex := func(s *Scanner) StateFn {
+		for i := 0; i < 10; i++ {
+			s.Emit(IDENTIFIER)
+		}
+		close(done)
+		return nil
+	}
+	s := New("batch", "", lex)
+	<-done // the client is busy while the lexer emits
+	s.NextItem()
+	if n := len(s.received); n != 9 {
+		t.Errorf("got %d further items in the batch, expected 9", n)
+	}
+	if items := drain(s); len(items) != 10 {
+		t.Errorf("got %d remaining items, expected 10", len(items))
+	}
+}
+
+type acceptRunTest struct {
+	valid string
+	input string
+	run   string
+}
+
+var acceptRunTests = []acceptRunTest{
+	{"0123456789", "123abc", "123"},
+	{"0123456789", "123", "123"},
+	{"0123456789", "", ""},
+	{"abc", "abcé", "abc"},
+	{"aé", "aéaéb", "aéaé"},
+	{"éü", "éüa", "éü"},
+	{"a�", "a\xffb", "a\xff"},
+}
+
+func TestAcceptRun(t *testing.T) {
+	for _, test := range acceptRunTests {
+		s := &Scanner{input: test.input}
+		s.AcceptRun(test.valid)
+		if got := s.Text(); got != test.run {
+			t.Errorf("AcceptRun(%q) on %q: got %q expected %q", test.valid, test.input, got, test.run)
+		}
+	}
+}
+
+func benchmarkAcceptRun(b *testing.B, valid, input string) {
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		s := &Scanner{input: input}
+		s.AcceptRun(valid)
+	}
+}
+
+func BenchmarkAcceptRunDigits(b *testing.B) {
+	benchmarkAcceptRun(b, "0123456789", strings.Repeat("9", 4096))
+}
+
+func BenchmarkAcceptRunIdentifier(b *testing.B) {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_0123456789"
+	benchmarkAcceptRun(b, letters, strings.Repeat("z", 4096))
+}
+
+func BenchmarkAcceptRunUnicode(b *testing.B) {
+	benchmarkAcceptRun(b, "aéü", strings.Repeat("aé", 2048))
+}
@@ -0,0 +1,121 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package scantest provides helpers for testing lexers written with
+// package scan: collecting the items of a scan, comparing item streams
+// with readable diffs, and golden files.
+package scantest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/schulze/scan"
+)
+
+// update makes Golden rewrite the golden files instead of comparing them.
+var update = flag.Bool("scantest.update", false, "update golden files")
+
+// Collect returns the items of s up to and including the first EOF item.
+func Collect(s *scan.Scanner) []scan.Item {
+	var items []scan.Item
+	for {
+		item := s.NextItem()
+		items = append(items, item)
+		if item.Typ == scan.EOF {
+			return items
+		}
+	}
+}
+
+// RequireItems fails the test if got differs from want, comparing the
+// fields of the items not excluded by flags, and reports the differences
+// as produced by scan.Diff.
+func RequireItems(t testing.TB, got, want []scan.Item, flags scan.DiffFlags) {
+	t.Helper()
+	if diff := scan.Diff(want, got, flags); diff != "" {
+		t.Fatalf("items differ (-want +got):\n%s", diff)
+	}
+}
+
+// Golden compares items with the golden file testdata/name.golden, which
+// holds items in the format of scan.Fprint, and fails the test with a diff
+// if they differ. Run the tests with the -scantest.update flag to write
+// the golden files instead.
+func Golden(t testing.TB, name string, items []scan.Item) {
+	t.Helper()
+	GoldenNames(t, name, items, nil)
+}
+
+// GoldenNames is like Golden but writes and reads the type names in
+// names, such as those of a scanner returned by its TypeNames method.
+func GoldenNames(t testing.TB, name string, items []scan.Item, names scan.TypeNames) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		var b bytes.Buffer
+		if err := names.Fprint(&b, items); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll("testdata", 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b.Bytes(), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -scantest.update to create it)", err)
+	}
+	want, err := parse(string(data), names)
+	if err != nil {
+		t.Fatalf("%s:%v", path, err)
+	}
+	if diff := names.Diff(want, items, 0); diff != "" {
+		t.Fatalf("items differ from %s (-want +got):\n%s", path, diff)
+	}
+}
+
+// parse parses items in the format of scan.Fprint with the type names in
+// names.
+func parse(text string, names scan.TypeNames) ([]scan.Item, error) {
+	var items []scan.Item
+	for i, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%d: malformed item %q", i+1, line)
+		}
+		pos, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%d: bad position %q", i+1, fields[0])
+		}
+		typ, ok := names.Type(fields[1])
+		if !ok {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("%d: unknown type %q", i+1, fields[1])
+			}
+			typ = scan.ItemType(n)
+		}
+		rest := strings.TrimSpace(line)[len(fields[0]):]
+		rest = strings.TrimSpace(strings.TrimSpace(rest)[len(fields[1]):])
+		val, err := strconv.Unquote(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%d: bad value %s", i+1, rest)
+		}
+		items = append(items, scan.Item{Typ: typ, Pos: scan.Pos(pos), Val: val})
+	}
+	return items, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scantest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/schulze/scan"
+)
+
+const (
+	WORD = iota + 1
+)
+
+func init() {
+	scan.RegisterTypeName(WORD, "WORD")
+}
+
+// lexWords scans words separated by spaces.
+func lexWords(s *scan.Scanner) scan.StateFn {
+	switch r := s.Peek(); {
+	case r == scan.EOF:
+		s.Emit(scan.EOF)
+		return nil
+	case r == ' ':
+		s.AcceptRun(" ")
+		s.Ignore()
+	default:
+		for r := s.Next(); r != ' ' && r != scan.EOF; r = s.Next() {
+		}
+		s.Backup()
+		s.Emit(WORD)
+	}
+	return lexWords
+}
+
+// recorder records the failures reported by the helpers.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestRequireItems(t *testing.T) {
+	got := Collect(scan.New("words", "a  \"b\"", lexWords))
+	want := []scan.Item{
+		{Typ: WORD, Pos: 0, Val: "a"},
+		{Typ: WORD, Pos: 3, Val: `"b"`},
+		{Typ: scan.EOF, Pos: 6},
+	}
+	RequireItems(t, got, want, 0)
+	r := &recorder{TB: t}
+	want[1].Val = "b"
+	RequireItems(r, got, want, 0)
+	expect := "items differ (-want +got):\n-[1] WORD 3 \"b\"\n+[1] WORD 3 \"\\\"b\\\"\"\n"
+	if len(r.failures) != 1 || r.failures[0] != expect {
+		t.Errorf("got failures %q, expected %q", r.failures, expect)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "words", Collect(scan.New("words", "a  \"b c\" 12", lexWords)))
+	r := &recorder{TB: t}
+	Golden(r, "words", Collect(scan.New("words", "a  \"b c\" 13", lexWords)))
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], `+[3] WORD 9 "13"`) {
+		t.Errorf("got failures %q", r.failures)
+	}
+}
+
+func TestGoldenNames(t *testing.T) {
+	s := scan.New("words", "a  \"b c\" 12", lexWords)
+	s.SetTypeNames(map[scan.ItemType]string{WORD: "Word"})
+	GoldenNames(t, "words_names", Collect(s), s.TypeNames())
+}
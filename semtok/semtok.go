@@ -0,0 +1,118 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package semtok encodes the items of a scan.Scanner as semantic tokens
+// of the Language Server Protocol, for language servers built on lexers
+// written with package scan.
+//
+// The LSP represents the semantic tokens of a document as an array of
+// unsigned integers, five per token: the line of the token relative to the
+// previous token, its start character relative to the previous token if
+// both are on the same line and relative to the start of the line
+// otherwise, its length, its type and its modifiers.
+package semtok
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/schulze/scan"
+)
+
+// A Token describes how items of one type are reported: as an index into
+// the token types of the server's legend and a bit set of indices into its
+// token modifiers.
+type Token struct {
+	Type      uint32
+	Modifiers uint32
+}
+
+// An Encoding is the unit in which characters are counted, as negotiated
+// with the client through the positionEncoding capability.
+type Encoding int
+
+const (
+	UTF16 Encoding = iota // UTF-16 code units; the default of the protocol
+	UTF8                  // bytes
+	UTF32                 // runes
+)
+
+// An Encoder encodes items as semantic tokens.
+type Encoder struct {
+	Legend   map[scan.ItemType]Token // tokens for item types; items of other types are not reported
+	Encoding Encoding
+}
+
+// Encode returns the semantic tokens for items, which were scanned from
+// input, in the delta-encoded form of the LSP. Items spanning several
+// lines are split into one token per line, as most clients do not support
+// multiline tokens. Empty items are not reported.
+func (e *Encoder) Encode(input string, items []scan.Item) []uint32 {
+	lines := lineStarts(input)
+	var data []uint32
+	var prevLine, prevChar uint32
+	add := func(line int, start, end int, tok Token) {
+		lineStart := lines[line]
+		char := e.length(input[lineStart:start])
+		length := e.length(input[start:end])
+		deltaLine := uint32(line) - prevLine
+		deltaChar := char
+		if deltaLine == 0 {
+			deltaChar -= prevChar
+		}
+		data = append(data, deltaLine, deltaChar, length, tok.Type, tok.Modifiers)
+		prevLine, prevChar = uint32(line), char
+	}
+	for _, item := range items {
+		tok, ok := e.Legend[item.Typ]
+		if !ok || item.Val == "" {
+			continue
+		}
+		start, end := int(item.Pos), int(item.End())
+		line := sort.SearchInts(lines, start+1) - 1
+		for start < end {
+			stop := end
+			if i := strings.IndexByte(input[start:end], '\n'); i >= 0 {
+				stop = start + i
+			}
+			if stop > start {
+				add(line, start, stop, tok)
+			}
+			start = stop + 1
+			line++
+		}
+	}
+	return data
+}
+
+// length returns the length of text in the units of the encoding.
+func (e *Encoder) length(text string) uint32 {
+	switch e.Encoding {
+	case UTF8:
+		return uint32(len(text))
+	case UTF32:
+		return uint32(utf8.RuneCountInString(text))
+	}
+	n := 0
+	for _, r := range text {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return uint32(n)
+}
+
+// lineStarts returns the offsets of the beginnings of the lines of input.
+func lineStarts(input string) []int {
+	lines := []int{0}
+	for i := 0; i < len(input); i++ {
+		if input[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,46 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package semtok
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/schulze/scan"
+)
+
+const (
+	WORD = iota
+	STRING
+)
+
+func TestEncode(t *testing.T) {
+	input := "ab 𝄞x\n\n  \"c\nde\" f"
+	items := []scan.Item{
+		{Typ: WORD, Pos: 0, Val: "ab"},
+		{Typ: WORD, Pos: 3, Val: "𝄞x"},
+		{Typ: STRING, Pos: 12, Val: "\"c\nde\""},
+		{Typ: WORD, Pos: 19, Val: "f"},
+		{Typ: scan.EOF, Pos: 20},
+	}
+	e := &Encoder{Legend: map[scan.ItemType]Token{
+		WORD:   {Type: 0},
+		STRING: {Type: 1, Modifiers: 1<<0 | 1<<2},
+	}}
+	expected := []uint32{
+		0, 0, 2, 0, 0, // ab
+		0, 3, 3, 0, 0, // 𝄞x is 3 UTF-16 code units
+		2, 2, 2, 1, 5, // "c
+		1, 0, 3, 1, 5, // de"
+		0, 4, 1, 0, 0, // f
+	}
+	if got := e.Encode(input, items); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", got, expected)
+	}
+	e.Encoding = UTF32
+	if got := e.Encode(input, items); got[7] != 2 {
+		t.Errorf("got length %d for 𝄞x in UTF-32, expected 2", got[7])
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SkipLineComment skips a comment that starts with prefix at the current
+// position and extends to the end of the line. The newline itself is not
+// skipped. SkipLineComment reports whether a comment was skipped.
+func (s *Scanner) SkipLineComment(prefix string) bool {
+	if !strings.HasPrefix(s.input[s.pos:], prefix) {
+		return false
+	}
+	if i := s.newlines.lineEnd(s.input[s.pos:]); i >= 0 {
+		s.pos += Pos(i)
+	} else {
+		s.pos = Pos(len(s.input))
+	}
+	s.width = 0
+	s.Ignore()
+	return true
+}
+
+// SkipBlockComment skips a comment delimited by open and close that starts
+// at the current position. If nested is true, comments may be nested, and
+// an opening delimiter takes precedence over a closing one where both
+// match. A comment that is not closed before the end of the input is
+// reported with an error item at its start and the rest of the input is
+// skipped. Text pending before the comment is ignored along with it.
+// SkipBlockComment reports whether a comment was skipped.
+func (s *Scanner) SkipBlockComment(open, close string, nested bool) bool {
+	if !strings.HasPrefix(s.input[s.pos:], open) {
+		return false
+	}
+	p := s.pos
+	s.pos += Pos(len(open))
+	s.width = 0
+	for level := 1; level > 0; {
+		rest := s.input[s.pos:]
+		switch {
+		case nested && strings.HasPrefix(rest, open):
+			s.pos += Pos(len(open))
+			level++
+		case strings.HasPrefix(rest, close):
+			s.pos += Pos(len(close))
+			level--
+		case s.Next() == EOF:
+			s.errorAt(p, fmt.Errorf("unterminated comment starting at %s", s.lineCol(p)))
+			level = 0
+		}
+	}
+	s.width = 0
+	s.Ignore()
+	return true
+}
+
+// IgnoreRun consumes a run of runes from the valid set and discards it
+// together with any text pending before it, as AcceptRun followed by
+// Ignore would. It reports whether any input was consumed; if not, the
+// pending text is kept.
+func (s *Scanner) IgnoreRun(valid string) bool {
+	start := s.pos
+	s.AcceptRun(valid)
+	if s.pos == start {
+		return false
+	}
+	s.Ignore()
+	return true
+}
+
+// IgnoreWhile consumes the runes for which pred returns true and discards
+// them like IgnoreRun.
+func (s *Scanner) IgnoreWhile(pred func(rune) bool) bool {
+	start := s.pos
+	for r := s.Peek(); r != EOF && pred(r); r = s.Peek() {
+		s.Next()
+	}
+	if s.pos == start {
+		return false
+	}
+	s.Ignore()
+	return true
+}
+
+// SkipUntil advances to the next occurrence of str in the input, which it
+// finds with strings.Index rather than rune by rune, and reports whether
+// there is one. If there is none, it advances to the end of the input.
+// The text passed over stays pending, so the caller can emit or ignore it,
+// and str itself is not consumed. SkipUntil is meant for finding closing
+// delimiters such as "*/" or "-->" in large inputs.
+func (s *Scanner) SkipUntil(str string) bool {
+	i := strings.Index(s.input[s.pos:], str)
+	found := i >= 0
+	if !found {
+		i = len(s.input) - int(s.pos)
+	}
+	s.pos += Pos(i)
+	s.width = 0
+	return found
+}
+
+// A Whitespace describes the runes consumed by SkipSpace and what to do
+// with them. Compilers typically ignore white space, while formatters pass
+// it on as items.
+type Whitespace struct {
+	IsSpace func(r rune) bool // reports whether r is white space; nil means unicode.IsSpace
+	Emit    bool              // emit the white space as an item of type Type instead of ignoring it
+	Type    ItemType
+}
+
+// Predefined kinds of white space.
+var (
+	Spaces   = Whitespace{IsSpace: func(r rune) bool { return r == ' ' }}
+	Blanks   = Whitespace{IsSpace: func(r rune) bool { return r == ' ' || r == '\t' }}
+	AllSpace = Whitespace{IsSpace: unicode.IsSpace}
+)
+
+// SkipSpace consumes a run of white space as described by w and reports
+// whether there was any. Depending on w the white space is ignored or
+// emitted; any text pending before it is discarded in the first case and
+// becomes part of the item in the second.
+func (s *Scanner) SkipSpace(w Whitespace) bool {
+	isSpace := w.IsSpace
+	if isSpace == nil {
+		isSpace = unicode.IsSpace
+	}
+	start := s.pos
+	for r := s.Peek(); r != EOF && isSpace(r); r = s.Peek() {
+		s.Next()
+	}
+	if s.pos == start {
+		return false
+	}
+	if w.Emit {
+		s.Emit(w.Type)
+	} else {
+		s.Ignore()
+	}
+	return true
+}
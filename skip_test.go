@@ -0,0 +1,181 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"testing"
+	"unicode"
+)
+
+// lexBlock scans the language of lexStart, but skips its comments with
+// SkipBlockComment, which also copes with unterminated comments.
+func lexBlock(s *Scanner) StateFn {
+	switch r := s.Peek(); {
+	case s.SkipBlockComment("(*", "*)", true):
+	case r == EOF:
+		s.Emit(EOF)
+		return nil
+	case r == ' ' || r == '\n':
+		s.Next()
+		s.Ignore()
+	case isAlphaNumeric(r):
+		for isAlphaNumeric(s.Peek()) {
+			s.Next()
+		}
+		if unicode.IsLetter(r) {
+			s.Emit(IDENTIFIER)
+		} else {
+			s.Emit(INTEGER)
+		}
+	default:
+		typ, ok := map[rune]ItemType{'+': PLUS, '-': MINUS, '(': LPAREN, ')': RPAREN}[s.Next()]
+		if !ok {
+			return s.Errorf("lex error")
+		}
+		s.Emit(typ)
+	}
+	return lexBlock
+}
+
+func TestSkipLineComment(t *testing.T) {
+	s := New("line comment", "// a\nb // c", nil)
+	if !s.SkipLineComment("//") || s.Peek() != '\n' || s.Text() != "" {
+		t.Errorf("comment was not skipped up to the newline")
+	}
+	if s.SkipLineComment("//") {
+		t.Errorf("skipped a comment at a newline")
+	}
+	s.Seek(7)
+	if !s.SkipLineComment("//") || s.Peek() != EOF {
+		t.Errorf("comment was not skipped up to EOF")
+	}
+}
+
+func TestSkipBlockComment(t *testing.T) {
+	tests := []struct {
+		input  string
+		nested bool
+		rest   string
+	}{
+		{"/* a */b", false, "b"},
+		{"/* a /* b */ c */d", false, " c */d"},
+		{"/* a /* b */ c */d", true, "d"},
+		{"/**/", true, ""},
+	}
+	for _, test := range tests {
+		s := New(test.input, test.input, nil)
+		if !s.SkipBlockComment("/*", "*/", test.nested) {
+			t.Errorf("%q: no comment skipped", test.input)
+		}
+		if rest := s.input[s.pos:]; rest != test.rest {
+			t.Errorf("%q: got rest %q, expected %q", test.input, rest, test.rest)
+		}
+	}
+	if s := New("no comment", "a/**/", nil); s.SkipBlockComment("/*", "*/", false) {
+		t.Errorf("skipped a comment not at the current position")
+	}
+
+	items := drain(New("unterminated", "a\n (* b (* c *)", lexBlock))
+	expected := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: ERROR, Pos: 3, Val: "unterminated comment starting at 2:2"},
+		{Typ: EOF, Pos: 15},
+	}
+	if !equal(items, expected, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+	// lexPending skips a comment with text pending before it.
+	lexPending := func(s *Scanner) StateFn {
+		s.Next()
+		s.SkipBlockComment("(*", "*)", false)
+		s.Emit(EOF)
+		return nil
+	}
+	items = drain(New("pending", "a(* b", lexPending))
+	if items[0].Typ != ERROR || items[0].Pos != 1 || items[0].Val != "unterminated comment starting at 1:2" {
+		t.Errorf("got %+v, expected an error at the start of the comment", items[0])
+	}
+}
+
+const WHITESPACE = 400
+
+func TestSkipSpace(t *testing.T) {
+	tests := []struct {
+		w    Whitespace
+		text string
+		rest string
+	}{
+		{Spaces, "", "\t\n\u00a0x"},
+		{Blanks, "", "\n\u00a0x"},
+		{AllSpace, "", "x"},
+		{Whitespace{}, "", "x"},
+	}
+	for _, test := range tests {
+		s := New("space", "  \t\n\u00a0x", nil)
+		s.Next()
+		if !s.SkipSpace(test.w) {
+			t.Errorf("no white space skipped")
+		}
+		if rest := s.input[s.pos:]; s.Text() != test.text || rest != test.rest {
+			t.Errorf("got text %q and rest %q, expected %q and %q", s.Text(), rest, test.text, test.rest)
+		}
+		if s.SkipSpace(test.w) {
+			t.Errorf("skipped white space twice")
+		}
+	}
+	w := Blanks
+	w.Emit, w.Type = true, WHITESPACE
+	lexWords := func(s *Scanner) StateFn {
+		s.SkipSpace(w)
+		s.AcceptRun("abc")
+		s.Emit(IDENTIFIER)
+		s.SkipSpace(w)
+		s.Emit(EOF)
+		return nil
+	}
+	items := drain(New("emit space", "\t a \t", lexWords))
+	expected := []Item{{Typ: WHITESPACE, Val: "\t "}, {Typ: IDENTIFIER, Val: "a"}, {Typ: WHITESPACE, Val: " \t"}, tEOF}
+	if !equal(items, expected, false) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expected)
+	}
+}
+
+func TestIgnoreRun(t *testing.T) {
+	s := New("ignore", "ab  \t12x", nil)
+	s.Next()
+	if s.IgnoreRun(" \t") || s.Text() != "a" {
+		t.Errorf("IgnoreRun without a match: got pending %q, expected %q", s.Text(), "a")
+	}
+	s.Next()
+	if !s.IgnoreRun(" \t") || s.Text() != "" || s.Peek() != '1' {
+		t.Errorf("IgnoreRun did not skip to the digits")
+	}
+	isDigit := func(r rune) bool { return '0' <= r && r <= '9' }
+	if !s.IgnoreWhile(isDigit) || s.Text() != "" || s.Peek() != 'x' {
+		t.Errorf("IgnoreWhile did not skip the digits")
+	}
+	if s.IgnoreWhile(isDigit) {
+		t.Errorf("IgnoreWhile skipped a letter")
+	}
+	s.Next()
+	if s.IgnoreWhile(func(rune) bool { return true }) {
+		t.Errorf("IgnoreWhile skipped input at EOF")
+	}
+}
+
+func TestSkipUntil(t *testing.T) {
+	s := New("until", "<!-- a -- b -->c-->", nil)
+	s.Seek(4)
+	if !s.SkipUntil("-->") || s.Text() != " a -- b " {
+		t.Errorf("got pending %q, expected %q", s.Text(), " a -- b ")
+	}
+	if !s.SkipUntil("-->") || s.Text() != " a -- b " {
+		t.Errorf("SkipUntil moved past a match at the current position")
+	}
+	s.Seek(s.Pos() + 3)
+	if s.SkipUntil("<!--") || s.Text() != "c-->" || s.Peek() != EOF {
+		t.Errorf("got pending %q without a match, expected the rest of the input", s.Text())
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// SplitFunc returns a bufio.SplitFunc that splits its input into the items
+// produced by the state machine starting with state start, so that a lexer
+// built with this package can be used with a bufio.Scanner. The tokens are
+// the raw text of the items; their types are not available. The state
+// machine is restarted at the beginning of each token, so it must not
+// depend on context from previous tokens. An item that reaches the end of
+// the data read so far, or an error item before the end of the input, is
+// retried with more data; an error item at the end of the input stops the
+// bufio.Scanner with the item's error.
+func SplitFunc(start StateFn) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		s := &Scanner{input: string(data), state: start}
+		var item Item
+		found := false
+		s.sink = func(i Item) {
+			item, found = i, true
+			s.stopped = true
+		}
+		s.runStates()
+		end := int(item.End())
+		switch {
+		case !found || item.Typ == EOF:
+			if atEOF {
+				return len(data), nil, nil // skip trailing ignored text
+			}
+			return 0, nil, nil
+		case !atEOF && (item.Typ == ERROR || end == len(data)):
+			return 0, nil, nil
+		case item.Typ == ERROR:
+			return 0, nil, item.Err()
+		}
+		return end, data[item.Pos:end], nil
+	}
+}
+
+// maxEmptyTokens is the number of tokens without progress LexSplit accepts
+// in a row, the limit bufio.Scanner uses as well.
+const maxEmptyTokens = 100
+
+// LexSplit returns a state function that splits the input with split, as
+// a bufio.Scanner would, and emits the tokens as items of type t. It builds
+// a trivial lexer from an existing bufio.SplitFunc such as bufio.ScanWords.
+// An error returned by split is emitted as an error item and ends the scan,
+// as does a run of more than 100 tokens without progress.
+func LexSplit(split bufio.SplitFunc, t ItemType) StateFn {
+	var lex StateFn
+	lex = func(s *Scanner) StateFn {
+		for empty := 0; ; empty++ {
+			data := []byte(s.input[s.pos:])
+			advance, token, err := split(data, true)
+			if err != nil && err != bufio.ErrFinalToken {
+				return s.Errorf("%w", err)
+			}
+			if advance == 0 && token != nil && err == nil {
+				if empty == maxEmptyTokens {
+					return s.Errorf("split function returns too many empty tokens without progressing")
+				}
+				s.emitValue(t, string(token))
+				continue
+			}
+			next := s.pos + Pos(advance)
+			if token != nil {
+				// The text before the token is ignored, and a token that is
+				// not in the data spans all of it.
+				end := next
+				if i := bytes.Index(data[:advance], token); i >= 0 {
+					s.pos += Pos(i)
+					s.Ignore()
+					end = s.pos + Pos(len(token))
+				}
+				s.pos = end
+				s.emitValue(t, string(token))
+			}
+			s.pos = next
+			s.width = 0
+			s.Ignore()
+			if advance == 0 && token == nil || err == bufio.ErrFinalToken {
+				s.Emit(EOF)
+				return nil
+			}
+			return lex
+		}
+	}
+	return lex
+}
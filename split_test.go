@@ -0,0 +1,82 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSplitFunc(t *testing.T) {
+	input := "(12 + ab)  - (* c *) 3 "
+	// Read one byte at a time to make sure tokens are not cut short. The
+	// comment is cut short as well, which lexBlock copes with.
+	b := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(input)))
+	b.Split(SplitFunc(lexBlock))
+	var tokens []string
+	for b.Scan() {
+		tokens = append(tokens, b.Text())
+	}
+	if err := b.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := strings.Join(tokens, ","), "(,12,+,ab,),-,3"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	b = bufio.NewScanner(strings.NewReader("a ?"))
+	b.Split(SplitFunc(lexStart))
+	for b.Scan() {
+	}
+	if err := b.Err(); err == nil || err.Error() != "lex error" {
+		t.Errorf("got error %v, expected lex error", err)
+	}
+}
+
+func TestLexSplit(t *testing.T) {
+	items := drain(New("split", " ab  c\nd ", LexSplit(bufio.ScanWords, IDENTIFIER)))
+	expected := []Item{
+		{Typ: IDENTIFIER, Pos: 1, Val: "ab"},
+		{Typ: IDENTIFIER, Pos: 5, Val: "c"},
+		{Typ: IDENTIFIER, Pos: 7, Val: "d"},
+		{Typ: EOF, Pos: 9},
+	}
+	if !equal(items, expected, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", items, expected)
+	}
+}
+
+func TestLexSplitTrivia(t *testing.T) {
+	items := drain(New("split", " ab  c", LexSplit(bufio.ScanWords, IDENTIFIER), CollectTrivia(SPACE)))
+	for k, trivia := range []string{" ", "  ", ""} {
+		var got string
+		for _, item := range items[k].LeadingTrivia() {
+			got += item.Val
+		}
+		if got != trivia {
+			t.Errorf("%v: got trivia %q, expected %q", items[k], got, trivia)
+		}
+	}
+	// Only the spaces are reported as ignored in lossless mode.
+	items = drain(New("split", " ab  c", LexSplit(bufio.ScanWords, IDENTIFIER), Lossless()))
+	for _, item := range items {
+		if item.Typ == ERROR && !strings.HasPrefix(item.Val, `input " " ignored`) {
+			t.Errorf("got error %v in lossless mode", item)
+		}
+	}
+}
+
+func TestLexSplitNoProgress(t *testing.T) {
+	empty := func(data []byte, atEOF bool) (int, []byte, error) {
+		return 0, []byte{}, nil
+	}
+	items := drain(New("split", "abc", LexSplit(empty, IDENTIFIER)))
+	last := items[len(items)-2]
+	if len(items) != maxEmptyTokens+2 || last.Typ != ERROR {
+		t.Errorf("got %d items ending in %v, expected %d empty items and an error", len(items), last, maxEmptyTokens)
+	}
+}
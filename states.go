@@ -0,0 +1,151 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	stateMu      sync.RWMutex
+	stateNames   = make(map[uintptr]string) // names of registered state functions
+	statesByName = make(map[string]StateFn) // registered state functions by name
+)
+
+// stateKey identifies a state function. All closures created by the same
+// function literal share a key.
+func stateKey(fn StateFn) uintptr {
+	return reflect.ValueOf(fn).Pointer()
+}
+
+// RegisterState records a human-readable name for the state function fn.
+// The name is used by StateName and in the output of StateGraph, and
+// StateByName maps it back to fn, so that states can be referred to by
+// stable names in configuration, saved scanner states and documentation.
+//
+// Names and state functions correspond one to one, and RegisterState
+// panics if fn or name is already registered with another name or state
+// function. State functions are told apart by their code only, so
+// closures created by the same function literal, and method values of the
+// same method, such as the Lex methods of two Rules, count as the same
+// state function and cannot be registered under different names.
+func RegisterState(name string, fn StateFn) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	key := stateKey(fn)
+	if old, ok := stateNames[key]; ok && old != name {
+		panic(fmt.Sprintf("scan: RegisterState(%q): state function already registered as %q", name, old))
+	}
+	if old, ok := statesByName[name]; ok && stateKey(old) != key {
+		panic(fmt.Sprintf("scan: RegisterState(%q): name already registered for another state function", name))
+	}
+	stateNames[key] = name
+	statesByName[name] = fn
+}
+
+// StateByName returns the state function registered with name.
+func StateByName(name string) (StateFn, bool) {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	fn, ok := statesByName[name]
+	return fn, ok
+}
+
+// RegisteredStates returns the names of the registered state functions in
+// sorted order.
+func RegisteredStates() []string {
+	stateMu.RLock()
+	names := make([]string, 0, len(statesByName))
+	for name := range statesByName {
+		names = append(names, name)
+	}
+	stateMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// StateName returns the name registered for fn. For unregistered state
+// functions it returns the name of the Go function, without its package
+// path, and for nil it returns "nil".
+func StateName(fn StateFn) string {
+	if fn == nil {
+		return "nil"
+	}
+	return stateNameOf(stateKey(fn))
+}
+
+// stateNameOf implements StateName for the state function with key.
+func stateNameOf(key uintptr) string {
+	stateMu.RLock()
+	name, ok := stateNames[key]
+	stateMu.RUnlock()
+	if ok {
+		return name
+	}
+	f := runtime.FuncForPC(key)
+	if f == nil {
+		return fmt.Sprintf("state%#x", key)
+	}
+	name = f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// A StateGraph records the transitions between state functions observed
+// during scanning. Its Observe method has the signature expected by the
+// OnTransition option:
+//
+//	var g scan.StateGraph
+//	s := scan.New(name, input, start, scan.OnTransition(g.Observe))
+//
+// The zero value is an empty graph ready to use.
+type StateGraph struct {
+	mu    sync.Mutex
+	edges map[[2]string]int // number of transitions between two named states
+}
+
+// Observe records a transition from one state function to the next.
+func (g *StateGraph) Observe(from, to StateFn, pos Pos) {
+	edge := [2]string{StateName(from), StateName(to)}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.edges == nil {
+		g.edges = make(map[[2]string]int)
+	}
+	g.edges[edge]++
+}
+
+// WriteDOT writes the observed transitions as a Graphviz DOT graph with the
+// given name. Each edge is labeled with the number of times it was taken.
+func (g *StateGraph) WriteDOT(w io.Writer, name string) error {
+	g.mu.Lock()
+	edges := make([][2]string, 0, len(g.edges))
+	for e := range g.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", name)
+	for _, e := range edges {
+		fmt.Fprintf(&b, "\t%q -> %q [label=\"%d\"];\n", e[0], e[1], g.edges[e])
+	}
+	b.WriteString("}\n")
+	g.mu.Unlock()
+	_, err := io.WriteString(w, b.String())
+	return err
+}
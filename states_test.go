@@ -0,0 +1,117 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterState("start", lexStart)
+	RegisterState("identifier", lexIdentifier)
+	RegisterState("integer", lexInteger)
+	RegisterState("space", lexSpace)
+}
+
+func TestStateName(t *testing.T) {
+	tests := []struct {
+		fn   StateFn
+		name string
+	}{
+		{lexStart, "start"},
+		{lexOperator, "scan.lexOperator"},
+		{nil, "nil"},
+	}
+	for _, test := range tests {
+		if name := StateName(test.fn); name != test.name {
+			t.Errorf("got %q, expected %q", name, test.name)
+		}
+	}
+}
+
+func TestStateByName(t *testing.T) {
+	fn, ok := StateByName("integer")
+	if !ok || StateName(fn) != "integer" {
+		t.Errorf("StateByName(\"integer\"): got %s, %v", StateName(fn), ok)
+	}
+	if _, ok := StateByName("scan.lexOperator"); ok {
+		t.Errorf("StateByName found an unregistered state")
+	}
+
+	// Registering the same pair again is allowed, conflicting pairs are not.
+	RegisterState("op", lexOperator)
+	RegisterState("op", lexOperator)
+	for _, test := range []struct {
+		name string
+		fn   StateFn
+	}{
+		{"operator", lexOperator},
+		{"op", lexInterpolated},
+		{"op", (&Rules{}).Lex},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterState(%q, %s) did not panic", test.name, StateName(test.fn))
+				}
+			}()
+			RegisterState(test.name, test.fn)
+		}()
+	}
+	if name := StateName(lexOperator); name != "op" {
+		t.Errorf("state registered as op named %q after conflicts", name)
+	}
+	names := RegisteredStates()
+	if !sort.StringsAreSorted(names) || !strings.Contains(" "+strings.Join(names, " ")+" ", " op ") {
+		t.Errorf("RegisteredStates: got %s", names)
+	}
+	stateMu.Lock()
+	delete(statesByName, "op")
+	delete(stateNames, stateKey(lexOperator))
+	stateMu.Unlock()
+}
+
+func TestRegisterStateMethodValues(t *testing.T) {
+	a, b := &Rules{}, &Rules{}
+	RegisterState("rulesA", a.Lex)
+	defer func() {
+		stateMu.Lock()
+		delete(statesByName, "rulesA")
+		delete(stateNames, stateKey(a.Lex))
+		stateMu.Unlock()
+	}()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("method value of another Rules registered under a second name")
+		}
+	}()
+	RegisterState("rulesB", b.Lex)
+}
+
+func TestStateGraph(t *testing.T) {
+	var g StateGraph
+	s := New("graph", "ab 12 cd", lexStart, OnTransition(g.Observe))
+	drain(s)
+	s.NextItem() // wait for the state machine to finish
+	var b strings.Builder
+	if err := g.WriteDOT(&b, "test"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph "test" {
+	"identifier" -> "start" [label="2"];
+	"integer" -> "start" [label="1"];
+	"space" -> "start" [label="2"];
+	"start" -> "identifier" [label="2"];
+	"start" -> "integer" [label="1"];
+	"start" -> "nil" [label="1"];
+	"start" -> "space" [label="2"];
+}
+`
+	if b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// Stats describes the work done by a scan.
+type Stats struct {
+	Items    map[ItemType]int // number of items passed to the client, by type
+	Errors   int              // number of error items
+	Warnings int              // number of warning items
+	Bytes    int              // number of bytes of input consumed
+	Runes    int              // number of runes of input consumed
+	Elapsed  time.Duration    // duration of the scan
+}
+
+// statsState holds the statistics of a scan while it runs.
+type statsState struct {
+	Stats
+	started  time.Time
+	finished chan struct{} // closed when the statistics are complete
+}
+
+// CollectStats makes the scanner collect statistics about the scan, for
+// operators of services that scan a lot of input. The statistics are
+// returned by Stats.
+func CollectStats() Option {
+	return func(s *Scanner) {
+		s.stats = &statsState{
+			Stats:    Stats{Items: make(map[ItemType]int)},
+			finished: make(chan struct{}),
+		}
+	}
+}
+
+// Stats returns the statistics of the scan. It waits for the state
+// machine to finish, so it should be called after NextItem has returned
+// EOF. It returns zero statistics unless the scanner was created with the
+// CollectStats option.
+func (s *Scanner) Stats() Stats {
+	if s.stats == nil {
+		return Stats{}
+	}
+	<-s.stats.finished
+	return s.stats.Stats
+}
+
+// countItem adds item to the statistics.
+func (st *statsState) countItem(item Item) {
+	st.Items[item.Typ]++
+	switch item.Typ {
+	case ERROR:
+		st.Errors++
+	case WARNING:
+		st.Warnings++
+	}
+}
+
+// finishStats completes the statistics at the end of the scan.
+func (s *Scanner) finishStats() {
+	s.stats.Bytes = int(s.pos)
+	s.stats.Runes = utf8.RuneCountInString(s.input[:s.pos])
+	s.stats.Elapsed = time.Since(s.stats.started)
+	if s.metrics != nil {
+		s.metrics.ObserveScan(s.stats.Stats)
+	}
+	close(s.stats.finished)
+}
@@ -0,0 +1,46 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	s := New("stats", "a !bc", lexSpaced, CollectStats())
+	drain(s)
+	st := s.Stats()
+	if st.Items[IDENTIFIER] != 2 || st.Items[SPACE] != 1 || st.Items[EOF] != 1 {
+		t.Errorf("got items %v", st.Items)
+	}
+	if st.Errors != 1 || st.Warnings != 0 {
+		t.Errorf("got %d errors and %d warnings, expected 1 and 0", st.Errors, st.Warnings)
+	}
+	if st.Bytes != 5 {
+		t.Errorf("got %d bytes, expected 5", st.Bytes)
+	}
+	if st.Runes != 5 {
+		t.Errorf("got %d runes, expected 5", st.Runes)
+	}
+	if st.Elapsed <= 0 {
+		t.Errorf("got elapsed time %v", st.Elapsed)
+	}
+	if st := New("no stats", "", nil).Stats(); st.Items != nil {
+		t.Errorf("got %v without CollectStats", st)
+	}
+}
+
+func TestStatsRunes(t *testing.T) {
+	// lexRun consumes the input without calling Next.
+	lexRun := func(s *Scanner) StateFn {
+		s.AcceptRun("aä ")
+		s.Emit(IDENTIFIER)
+		s.Emit(EOF)
+		return nil
+	}
+	s := New("runes", "aa äa", lexRun, CollectStats())
+	drain(s)
+	if st := s.Stats(); st.Bytes != 6 || st.Runes != 5 {
+		t.Errorf("got %d bytes and %d runes, expected 6 and 5", st.Bytes, st.Runes)
+	}
+}
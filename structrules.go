@@ -0,0 +1,90 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	itemTypeType = reflect.TypeOf(ItemType(0))
+	stateFnType  = reflect.TypeOf(StateFn(nil))
+)
+
+// RulesFromStruct builds Rules from the fields of the struct v points to,
+// for lexers whose tokens are declared next to other types of a program:
+//
+//	var tokens struct {
+//		Number scan.ItemType `scan:"/[0-9]+/"`
+//		Plus   scan.ItemType `scan:"+"`
+//		Ident  scan.ItemType `scan:"/[a-z]+/"`
+//		Other  scan.ItemType // emitted by hand-written states
+//		_      struct{}      `scan:"/[ \t\n]+/"`
+//		String scan.StateFn  `scan:"\""`
+//	}
+//	rules := scan.RulesFromStruct(&tokens, 0)
+//
+// The scan tag of a field holds its pattern: a regular expression between
+// slashes, or literal text otherwise. RulesFromStruct assigns consecutive
+// item types, starting with first, to the exported fields of type ItemType
+// in order, and adds a rule emitting the item type for the fields with a
+// pattern. A blank field with a pattern adds a rule skipping the matched
+// text, and a field of type StateFn with a pattern, set before the call,
+// adds a rule passing control to the state function as with Rules.Goto.
+// The rules are added in field order, which decides between patterns
+// matching the same text.
+//
+// RulesFromStruct panics if v does not point to a struct or a field with
+// a pattern has another type, which makes it suitable for initializing
+// package-level variables.
+func RulesFromStruct(v interface{}, first ItemType) *Rules {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("scan: RulesFromStruct of %T, not a pointer to a struct", v))
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	rules := &Rules{}
+	next := first
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		pattern, hasPattern := f.Tag.Lookup("scan")
+		var m Matcher
+		if hasPattern {
+			m = patternMatcher(pattern)
+		}
+		switch {
+		case f.Name == "_":
+			if m != nil {
+				rules.Skip(m)
+			}
+		case f.Type == itemTypeType && f.IsExported():
+			rv.Field(i).SetInt(int64(next))
+			if m != nil {
+				rules.Add(m, next)
+			}
+			next++
+		case f.Type == stateFnType && m != nil:
+			fn, _ := rv.Field(i).Interface().(StateFn)
+			if fn == nil {
+				panic(fmt.Sprintf("scan: RulesFromStruct: state function %s is nil", f.Name))
+			}
+			rules.Goto(m, fn)
+		case m != nil:
+			panic(fmt.Sprintf("scan: RulesFromStruct: field %s with pattern has type %s", f.Name, f.Type))
+		}
+	}
+	return rules
+}
+
+// patternMatcher returns the matcher for the pattern of a scan tag.
+func patternMatcher(pattern string) Matcher {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return Regexp(pattern[1 : len(pattern)-1])
+	}
+	return Lit(pattern)
+}
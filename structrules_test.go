@@ -0,0 +1,75 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"testing"
+)
+
+type structTokens struct {
+	Number ItemType `scan:"/[0-9]+/"`
+	If     ItemType `scan:"if"`
+	Ident  ItemType `scan:"/[a-z]+/"`
+	Slash  ItemType `scan:"/"`
+	Other  ItemType
+	_      struct{} `scan:"/[ \t\n]+/"`
+	String StateFn  `scan:"\""`
+	other  int
+}
+
+func TestRulesFromStruct(t *testing.T) {
+	var tokens structTokens
+	var rules *Rules
+	tokens.String = func(s *Scanner) StateFn {
+		for s.Next() != '"' {
+		}
+		s.Emit(tokens.Other)
+		return rules.Lex
+	}
+	rules = RulesFromStruct(&tokens, 10)
+	if tokens.Number != 10 || tokens.Slash != 13 || tokens.Other != 14 {
+		t.Fatalf("got types %+v", tokens)
+	}
+	got := drain(New("struct", "if iffy / 42 \"a b\"", rules.Lex))
+	want := []Item{
+		{Typ: 11, Pos: 0, Val: "if"},
+		{Typ: 12, Pos: 3, Val: "iffy"},
+		{Typ: 13, Pos: 8, Val: "/"},
+		{Typ: 10, Pos: 10, Val: "42"},
+		{Typ: 14, Pos: 13, Val: "\"a b\""},
+		{Typ: EOF, Pos: 18},
+	}
+	if !equal(got, want, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", got, want)
+	}
+}
+
+func TestRulesFromStructPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"not a pointer", structTokens{}},
+		{"bad field type", &struct {
+			N int `scan:"1"`
+		}{}},
+		{"nil state", &struct {
+			S StateFn `scan:"x"`
+		}{}},
+		{"bad regexp", &struct {
+			T ItemType `scan:"/(/"`
+		}{}},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", test.name)
+				}
+			}()
+			RulesFromStruct(test.v, 0)
+		}()
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// A Snapshot records the state of a scanner between two state functions,
+// so that scanning can be resumed later over the same input with Resume.
+// Its fields are exported for serialization, with encoding/json for
+// example. State functions are recorded by the names registered with
+// RegisterState; a snapshot in an unregistered state cannot be resumed.
+// Since closures and method values of the same code share a registration,
+// Resume restores the state function registered under the name, not the
+// closure that was running.
+//
+// A snapshot covers the position, the pending text, the next state, the
+// states saved by PushState, the nesting depth and the depths of brackets.
+// Other state, such as indentation levels, trivia, history and included
+// sources, is not recorded, and lexers relying on it cannot be resumed.
+type Snapshot struct {
+	Pos     Pos      // current position
+	Start   Pos      // start of the pending text
+	State   string   // name of the next state function; empty at the end of the scan
+	Stack   []string // names of the states saved by PushState, oldest first
+	Nesting int      // nesting depth tracked with EnterNesting
+	Items   int      // number of items passed to the client before the snapshot
+
+	Depths map[ItemType]int // depths of brackets by kind; see Depth
+}
+
+// suspendState holds the snapshots the client may resume from.
+type suspendState struct {
+	mu        sync.Mutex
+	snapshots []Snapshot   // oldest first; the first one has been reached by the client
+	consumed  atomic.Int64 // number of items returned by NextItem, set by the client
+	fetched   int          // number of items received from the channel; client only
+}
+
+// Suspendable makes the scanner record snapshots for Suspend. Since the
+// lexer runs ahead of its client, the scanner keeps the snapshots taken
+// after the last item returned by NextItem until the client catches up.
+func Suspendable() Option {
+	return func(s *Scanner) {
+		s.suspend = &suspendState{}
+	}
+}
+
+// snapshot returns a snapshot of the current state of s.
+func (s *Scanner) snapshot() Snapshot {
+	snap := Snapshot{
+		Pos:     s.pos,
+		Start:   s.start,
+		Nesting: s.nesting,
+		Items:   s.emitted,
+		Depths:  maps.Clone(s.depths),
+	}
+	if s.state != nil {
+		snap.State = StateName(s.state)
+	}
+	for _, fn := range s.stack {
+		snap.Stack = append(snap.Stack, StateName(fn))
+	}
+	return snap
+}
+
+// record adds a snapshot and drops the ones the client has moved past.
+func (st *suspendState) record(snap Snapshot) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.snapshots = append(st.snapshots, snap)
+	consumed := int(st.consumed.Load())
+	for len(st.snapshots) > 1 && st.snapshots[1].Items <= consumed {
+		st.snapshots = st.snapshots[1:]
+	}
+}
+
+// consume updates the number of items returned to the client of s.
+// Items peeked or pushed back count as not returned.
+func (st *suspendState) consume(s *Scanner) {
+	st.consumed.Store(int64(st.fetched - len(s.received) - len(s.lookahead)))
+}
+
+// Suspend returns the latest snapshot taken before the scanner had passed
+// more items to the client than NextItem has returned. Resuming from it
+// produces the items after the first Items items of the scan; the client
+// has already seen the ones up to the last item returned by NextItem and
+// skips them. Suspend reports false if the scanner was not created with
+// the Suspendable option. It does not stop the scanner.
+func (s *Scanner) Suspend() (Snapshot, bool) {
+	st := s.suspend
+	if st == nil {
+		return Snapshot{}, false
+	}
+	consumed := st.fetched - len(s.received) - len(s.lookahead)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for i := len(st.snapshots) - 1; i >= 0; i-- {
+		if snap := st.snapshots[i]; snap.Items <= consumed {
+			snap.Stack = append([]string(nil), snap.Stack...)
+			return snap, true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// Resume returns a scanner that continues the scan recorded in snap over
+// input, which must be the input of the suspended scan. The options need
+// not be those of the suspended scanner; for limits such as MaxErrors and
+// statistics, and for the Items field of its own snapshots, the resumed
+// scanner counts afresh.
+func Resume(name, input string, snap Snapshot, opts ...Option) (*Scanner, error) {
+	if snap.Start < 0 || snap.Start > snap.Pos || int(snap.Pos) > len(input) {
+		return nil, fmt.Errorf("snapshot position %d out of range [0, %d]", snap.Pos, len(input))
+	}
+	s := &Scanner{
+		name:    name,
+		input:   input,
+		pos:     snap.Pos,
+		start:   snap.Start,
+		lastPos: snap.Start,
+		nesting: snap.Nesting,
+		depths:  maps.Clone(snap.Depths),
+	}
+	if snap.State != "" {
+		fn, ok := StateByName(snap.State)
+		if !ok {
+			return nil, fmt.Errorf("cannot resume in unregistered state %s", snap.State)
+		}
+		s.state = fn
+	}
+	for _, name := range snap.Stack {
+		fn, ok := StateByName(name)
+		if !ok {
+			return nil, fmt.Errorf("cannot resume with unregistered state %s on the stack", name)
+		}
+		s.stack = append(s.stack, fn)
+	}
+	s.launch(opts)
+	return s, nil
+}
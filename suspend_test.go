@@ -0,0 +1,108 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterState("outer", lexOuter)
+	RegisterState("inner", lexInner)
+}
+
+// lexOuter scans identifiers; numbers are in brackets, scanned by lexInner.
+func lexOuter(s *Scanner) StateFn {
+	switch r := s.Next(); {
+	case r == EOF:
+		s.Emit(EOF)
+		return nil
+	case r == '[':
+		s.Emit(LPAREN)
+		s.PushState(lexOuter)
+		return lexInner
+	default:
+		s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		s.Emit(IDENTIFIER)
+		return lexOuter
+	}
+}
+
+func lexInner(s *Scanner) StateFn {
+	switch r := s.Next(); {
+	case r == ' ':
+		s.Ignore()
+	case r == ']':
+		s.Emit(RPAREN)
+		return s.PopState()
+	case r == EOF:
+		return s.Errorf("unterminated brackets")
+	default:
+		s.AcceptRun("0123456789")
+		s.Emit(INTEGER)
+	}
+	return lexInner
+}
+
+func TestSuspendResume(t *testing.T) {
+	const input = "ab[1 23]cd[4]e"
+	full := drain(New("full", input, lexOuter))
+	for k := 0; k < len(full); k++ {
+		s := New("suspend", input, lexOuter, Suspendable())
+		for i := 0; i < k; i++ {
+			s.NextItem()
+		}
+		snap, ok := s.Suspend()
+		if !ok || snap.Items > k {
+			t.Fatalf("%d: got snapshot %+v, %v", k, snap, ok)
+		}
+		data, err := json.Marshal(snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Snapshot
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		r, err := Resume("resume", input, decoded)
+		if err != nil {
+			t.Fatalf("%d: %v", k, err)
+		}
+		if got := drain(r); !equal(got, full[snap.Items:], true) {
+			t.Errorf("%d: resumed from %s got\n\t%+v\nexpected\n\t%v", k, data, got, full[snap.Items:])
+		}
+	}
+}
+
+func TestSuspendPeek(t *testing.T) {
+	s := New("peek", "ab[1]", lexOuter, Suspendable())
+	s.PeekItemN(3)
+	if snap, ok := s.Suspend(); !ok || snap.Items != 0 {
+		t.Errorf("got %+v, %v, expected a snapshot before all items", snap, ok)
+	}
+	drain(s)
+	if _, ok := New("plain", "", lexOuter).Suspend(); ok {
+		t.Errorf("Suspend without Suspendable returned a snapshot")
+	}
+}
+
+func TestResumeErrors(t *testing.T) {
+	tests := []struct {
+		snap Snapshot
+		err  string
+	}{
+		{Snapshot{Pos: 10, State: "outer"}, "out of range"},
+		{Snapshot{State: "scan.lexOperator"}, "unregistered state scan.lexOperator"},
+		{Snapshot{State: "outer", Stack: []string{"nope"}}, "unregistered state nope on the stack"},
+	}
+	for _, test := range tests {
+		_, err := Resume("resume", "abc", test.snap)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%+v: got error %v, expected %q", test.snap, err, test.err)
+		}
+	}
+}
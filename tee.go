@@ -0,0 +1,37 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// Tee makes NextItem pass a copy of each scanned item to record before
+// returning it, so that the stream a parser consumes can be recorded and
+// later fed back with Replay. record runs on the client's goroutine, when
+// the items are received from the scanner; this may be ahead of NextItem,
+// as items arrive in batches and may be looked at with PeekItemN. The
+// EOF items NextItem synthesizes after the scan has finished are not
+// recorded.
+func Tee(record func(Item)) Option {
+	return func(s *Scanner) {
+		s.tee = record
+	}
+}
+
+// Replay creates a scanner that passes back the recorded items instead of
+// scanning, so that parsers can be tested deterministically without
+// running their lexer. The input is used only to resolve positions and to
+// place the final EOF item; it may be empty. Options such as Filter and
+// Map apply to the replayed items as they would to scanned ones.
+func Replay(name, input string, items []Item, opts ...Option) *Scanner {
+	i := 0
+	var replay StateFn
+	replay = func(s *Scanner) StateFn {
+		if i == len(items) {
+			return nil
+		}
+		s.emit(items[i])
+		i++
+		return replay
+	}
+	return New(name, input, replay, opts...)
+}
@@ -0,0 +1,38 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestTeeReplay(t *testing.T) {
+	const input = "a !b"
+	var recorded []Item
+	items := drain(New("tee", input, lexSpaced, Tee(func(item Item) {
+		recorded = append(recorded, item)
+	})))
+	if !equal(recorded, items, true) {
+		t.Fatalf("recorded: got\n\t%+v\nexpected\n\t%v", recorded, items)
+	}
+	s := Replay("replay", input, recorded)
+	replayed := drain(s)
+	if !equal(replayed, items, true) {
+		t.Errorf("replayed: got\n\t%+v\nexpected\n\t%v", replayed, items)
+	}
+	if errs := s.Errors(); len(errs) != 1 || errs[0].Err() == nil {
+		t.Errorf("replayed errors: got %v", errs)
+	}
+}
+
+func TestReplayWithoutEOF(t *testing.T) {
+	recorded := []Item{{Typ: IDENTIFIER, Pos: 0, Val: "a"}, {Typ: SPACE, Pos: 1, Val: " "}}
+	items := drain(Replay("replay", "a ", recorded, Filter(notType(SPACE))))
+	expect := []Item{
+		{Typ: IDENTIFIER, Pos: 0, Val: "a"},
+		{Typ: EOF, Pos: 2, Val: ""},
+	}
+	if !equal(items, expect, true) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%v", items, expect)
+	}
+}
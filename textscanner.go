@@ -0,0 +1,99 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"os"
+	"text/scanner"
+	"unicode/utf8"
+)
+
+// A TextScanner presents the items of a Scanner through the API of
+// text/scanner's Scanner, easing the migration of code that consumes
+// text/scanner tokens to a lexer with custom token rules.
+type TextScanner struct {
+	Scanner *Scanner
+
+	// Tokens maps item types to the token values returned by Scan, such as
+	// scanner.Ident or scanner.Int. Items of other types whose value is a
+	// single rune are returned as that rune, as text/scanner does for
+	// operators and punctuation.
+	Tokens map[ItemType]rune
+
+	// Error is called for each error item. If no Error function is set,
+	// the error is reported to os.Stderr.
+	Error func(s *TextScanner, msg string)
+
+	// ErrorCount is incremented by one for each error item.
+	ErrorCount int
+
+	// Warning is called for each warning item, if set. Warnings are not
+	// counted in ErrorCount and are otherwise skipped.
+	Warning func(s *TextScanner, msg string)
+
+	// Start position of the most recently scanned token; set by Scan.
+	scanner.Position
+
+	item Item // the most recently scanned item
+}
+
+// NewTextScanner returns a TextScanner reading the items of s.
+func NewTextScanner(s *Scanner, tokens map[ItemType]rune) *TextScanner {
+	return &TextScanner{Scanner: s, Tokens: tokens}
+}
+
+// Scan reads the next item and returns its token value. Error and warning
+// items are reported and skipped. At the end of the input, Scan returns scanner.EOF.
+func (t *TextScanner) Scan() rune {
+	for {
+		t.item = t.Scanner.NextItem()
+		t.Position = t.position(t.item.Pos)
+		switch t.item.Typ {
+		case EOF:
+			return scanner.EOF
+		case ERROR:
+			t.error(t.item.Val)
+			continue
+		case WARNING:
+			if t.Warning != nil {
+				t.Warning(t, t.item.Val)
+			}
+			continue
+		}
+		if tok, ok := t.Tokens[t.item.Typ]; ok {
+			return tok
+		}
+		if r, size := utf8.DecodeRuneInString(t.item.Val); size > 0 && size == len(t.item.Val) {
+			return r
+		}
+		t.error(fmt.Sprintf("no token value for item %v", t.item))
+	}
+}
+
+// TokenText returns the text of the most recently scanned token.
+func (t *TextScanner) TokenText() string {
+	return t.item.Val
+}
+
+// Pos returns the position immediately after the most recently scanned
+// token.
+func (t *TextScanner) Pos() scanner.Position {
+	return t.position(t.item.End())
+}
+
+func (t *TextScanner) position(p Pos) scanner.Position {
+	pos := t.Scanner.Position(p)
+	return scanner.Position{Filename: pos.Name, Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
+}
+
+func (t *TextScanner) error(msg string) {
+	t.ErrorCount++
+	if t.Error != nil {
+		t.Error(t, msg)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s\n", t.Position, msg)
+}
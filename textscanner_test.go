@@ -0,0 +1,76 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/scanner"
+	"unicode"
+)
+
+// lexTokens scans identifiers, integers and plus signs, reporting other
+// runes as errors.
+func lexTokens(s *Scanner) StateFn {
+	s.SkipSpace(AllSpace)
+	switch r := s.Next(); {
+	case r == EOF:
+		s.Emit(EOF)
+		return nil
+	case r == '+':
+		s.Emit(PLUS)
+	case unicode.IsLetter(r):
+		s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		s.Emit(IDENTIFIER)
+	case unicode.IsDigit(r):
+		s.AcceptRun("0123456789")
+		s.Emit(INTEGER)
+	default:
+		s.EmitError("bad token")
+		s.Ignore()
+	}
+	return lexTokens
+}
+
+func TestTextScanner(t *testing.T) {
+	s := New("text", "a + ?\n 12", lexTokens)
+	ts := NewTextScanner(s, map[ItemType]rune{IDENTIFIER: scanner.Ident, INTEGER: scanner.Int})
+	var errs []string
+	ts.Error = func(ts *TextScanner, msg string) {
+		errs = append(errs, fmt.Sprintf("%s: %s", ts.Position, msg))
+	}
+	var out []string
+	for tok := ts.Scan(); tok != scanner.EOF; tok = ts.Scan() {
+		out = append(out, fmt.Sprintf("%s %s %q %s", ts.Position, scanner.TokenString(tok), ts.TokenText(), ts.Pos()))
+	}
+	expected := []string{
+		`text:1:1 Ident "a" text:1:2`,
+		`text:1:3 "+" "+" text:1:4`,
+		`text:2:2 Int "12" text:2:4`,
+	}
+	if got := strings.Join(out, "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("got\n%s\nexpected\n%s", got, strings.Join(expected, "\n"))
+	}
+	if ts.ErrorCount != 1 || len(errs) != 1 || errs[0] != "text:1:5: bad token" {
+		t.Errorf("got %d errors %q", ts.ErrorCount, errs)
+	}
+}
+
+func TestTextScannerWarning(t *testing.T) {
+	ts := NewTextScanner(New("text", "a\u202Eb c", lexBidi, DetectBidi(WARNING)), map[ItemType]rune{IDENTIFIER: scanner.Ident})
+	var warnings []string
+	ts.Warning = func(ts *TextScanner, msg string) {
+		warnings = append(warnings, fmt.Sprintf("%s: %s", ts.Position, msg))
+	}
+	for _, tok := range []rune{scanner.Ident, scanner.Ident, scanner.EOF} {
+		if got := ts.Scan(); got != tok {
+			t.Errorf("got %s, expected %s", scanner.TokenString(got), scanner.TokenString(tok))
+		}
+	}
+	if ts.ErrorCount != 0 || len(warnings) != 1 || warnings[0] != "text:1:2: bidirectional control character U+202E at 1:2" {
+		t.Errorf("got %d errors and warnings %q", ts.ErrorCount, warnings)
+	}
+}
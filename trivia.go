@@ -0,0 +1,59 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+// triviaState holds the state of trivia collection.
+type triviaState struct {
+	enabled bool
+	typ     ItemType // type of trivia collected by Ignore
+	pending []Item   // trivia collected since the last item
+}
+
+// CollectTrivia makes the scanner collect the input skipped by the lexer,
+// such as white space and comments, and attach it to the next emitted
+// item, so that a parser sees a clean stream of tokens while a formatter
+// can still recover every byte of the input. Text skipped with Ignore is
+// recorded as trivia of type t; lexers can record trivia of more specific
+// types with EmitTrivia. Error and warning items do not take trivia.
+func CollectTrivia(t ItemType) Option {
+	return func(s *Scanner) {
+		s.trivia = triviaState{enabled: true, typ: t}
+	}
+}
+
+// EmitTrivia records the pending input as trivia of type t, to be attached
+// to the next emitted item. If trivia are not collected, EmitTrivia is
+// equivalent to Ignore.
+func (s *Scanner) EmitTrivia(t ItemType) {
+	if !s.trivia.enabled {
+		s.Ignore()
+		return
+	}
+	if s.pos > s.start {
+		s.addTrivia(t)
+	}
+	s.start = s.pos
+}
+
+func (s *Scanner) addTrivia(t ItemType) {
+	item := Item{Typ: t, Pos: s.start, Val: s.input[s.start:s.pos]}.withEnd(s.pos)
+	if s.bidi.enabled && !s.bidiAllowed(t) {
+		s.checkBidi(item.Pos, item.Val)
+	}
+	if s.lossless {
+		s.cover(item)
+	}
+	s.trivia.pending = append(s.trivia.pending, item)
+}
+
+// LeadingTrivia returns the trivia preceding the item, in the order they
+// appear in the input. It is only set if the scanner was created with the
+// CollectTrivia option.
+func (i Item) LeadingTrivia() []Item {
+	if i.trivia == nil {
+		return nil
+	}
+	return *i.trivia
+}
@@ -0,0 +1,115 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+const (
+	SPACE = iota + 700
+	COMMENT
+)
+
+// lexTrivia scans identifiers separated by spaces, line comments starting
+// with '#' and '%' trivia.
+var lexTrivia = lexTriviaSpace(AllSpace)
+
+// lexTriviaSpace returns a lexer like lexTrivia that handles white space
+// as described by w.
+func lexTriviaSpace(w Whitespace) StateFn {
+	var lex StateFn
+	lex = func(s *Scanner) StateFn {
+		switch {
+		case s.SkipSpace(w):
+		case s.SkipLineComment("#"):
+		case s.Peek() == EOF:
+			s.Emit(EOF)
+			return nil
+		case s.Peek() == '%':
+			s.Next()
+			s.EmitTrivia(COMMENT)
+		default:
+			s.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+			s.Emit(IDENTIFIER)
+		}
+		return lex
+	}
+	return lex
+}
+
+func TestTrivia(t *testing.T) {
+	items := drain(New("trivia", "a # one\n%b ", lexTrivia, CollectTrivia(SPACE)))
+	expected := [][]Item{
+		nil,
+		{{Typ: SPACE, Pos: 1, Val: " "}, {Typ: SPACE, Pos: 2, Val: "# one"}, {Typ: SPACE, Pos: 7, Val: "\n"}, {Typ: COMMENT, Pos: 8, Val: "%"}},
+		{{Typ: SPACE, Pos: 10, Val: " "}},
+	}
+	if len(items) != 3 {
+		t.Fatalf("got %v, expected two identifiers and EOF", items)
+	}
+	for i, item := range items {
+		if trivia := item.LeadingTrivia(); !equal(trivia, expected[i], true) {
+			t.Errorf("%v: got trivia %+v, expected %+v", item, trivia, expected[i])
+		}
+	}
+	for _, item := range drain(New("no trivia", "a # one\n%b ", lexTrivia)) {
+		if item.LeadingTrivia() != nil {
+			t.Errorf("%v: got trivia without CollectTrivia", item)
+		}
+	}
+}
+
+func TestLossless(t *testing.T) {
+	w := AllSpace
+	w.Emit, w.Type = true, SPACE
+	lexEmitSpace := lexTriviaSpace(w)
+	tests := []struct {
+		name  string
+		start StateFn
+		opts  []Option
+		errs  []string
+	}{
+		{"emitted space", lexEmitSpace, []Option{Lossless()}, []string{
+			`input "# one" ignored in lossless mode at 1:3`,
+			`input "%" ignored in lossless mode at 2:1`,
+		}},
+		{"ignored", lexTrivia, []Option{Lossless()}, []string{
+			`input " " ignored in lossless mode at 1:2`,
+			`input "# one" ignored in lossless mode at 1:3`,
+			`input "\n" ignored in lossless mode at 1:8`,
+			`input "%" ignored in lossless mode at 2:1`,
+			`input " " ignored in lossless mode at 2:3`,
+		}},
+		{"trivia", lexTrivia, []Option{Lossless(), CollectTrivia(SPACE)}, nil},
+	}
+	for _, test := range tests {
+		var errs []string
+		for _, item := range drain(New(test.name, "a # one\n%b ", test.start, test.opts...)) {
+			if item.Typ == ERROR {
+				errs = append(errs, item.Val)
+			}
+		}
+		if len(errs) != len(test.errs) {
+			t.Errorf("%s: got errors %q, expected %q", test.name, errs, test.errs)
+			continue
+		}
+		for i := range errs {
+			if errs[i] != test.errs[i] {
+				t.Errorf("%s: got error %q, expected %q", test.name, errs[i], test.errs[i])
+			}
+		}
+	}
+	gap := func(s *Scanner) StateFn {
+		s.Next()
+		s.Emit(IDENTIFIER)
+		s.Next()
+		s.start = s.pos // skip without Ignore
+		s.Emit(EOF)
+		return nil
+	}
+	items := drain(New("gap", "ab", gap, Lossless()))
+	if len(items) != 3 || items[1].Val != `input "b" not covered by any item at 1:2` {
+		t.Errorf("got %v, expected an error for the gap", items)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+var (
+	typeMu    sync.RWMutex
+	typeNames = map[ItemType]string{
+		WARNING: "WARNING",
+		ERROR:   "ERROR",
+		EOF:     "EOF",
+	}
+	typesByName = map[string]ItemType{
+		"WARNING": WARNING,
+		"ERROR":   ERROR,
+		"EOF":     EOF,
+	}
+	nextSpace = firstSpace // base of the next type space; guarded by typeMu
+)
+
+// firstSpace is the base of the first type space allocated by NewTypeSpace.
+// Types below it are left to clients declaring their constants directly.
+const firstSpace ItemType = 1 << 16
+
+// NewTypeSpace reserves n consecutive item types and returns the first of
+// them. The spaces returned never overlap each other or the types below
+// 1<<16, so lexer packages can offset their iota constants by a space of
+// their own and be combined in one program without their types colliding:
+//
+//	var base = scan.NewTypeSpace(numTypes)
+//	...
+//	s.Emit(base + Ident)
+//
+// NewTypeSpace panics if n is negative or the types are exhausted.
+func NewTypeSpace(n int) ItemType {
+	typeMu.Lock()
+	defer typeMu.Unlock()
+	if n < 0 || ItemType(n) > math.MaxInt-nextSpace {
+		panic(fmt.Sprintf("scan: cannot reserve %d item types", n))
+	}
+	base := nextSpace
+	nextSpace += ItemType(n)
+	return base
+}
+
+// RegisterTypeName records name as the name of the client item type t.
+// Type names are used when items are printed and serialized. Registering a
+// new name for a type replaces the old one.
+func RegisterTypeName(t ItemType, name string) {
+	typeMu.Lock()
+	defer typeMu.Unlock()
+	if old, ok := typeNames[t]; ok {
+		delete(typesByName, old)
+	}
+	typeNames[t] = name
+	typesByName[name] = t
+}
+
+// TypeName returns the name registered for t. If there is none, it
+// returns the number of the type in decimal.
+func TypeName(t ItemType) string {
+	typeMu.RLock()
+	name, ok := typeNames[t]
+	typeMu.RUnlock()
+	if ok {
+		return name
+	}
+	return strconv.Itoa(int(t))
+}
+
+// TypeByName returns the item type registered with name.
+func TypeByName(name string) (ItemType, bool) {
+	typeMu.RLock()
+	defer typeMu.RUnlock()
+	t, ok := typesByName[name]
+	return t, ok
+}
+
+// TypeNames maps item types to names, for lexers that name their types
+// without registering them for the whole program with RegisterTypeName.
+// Types missing from the map keep their registered names. The zero value
+// holds no names; its methods then use the registered names only.
+type TypeNames map[ItemType]string
+
+// Name returns the name of t in n or, if there is none, TypeName(t).
+func (n TypeNames) Name(t ItemType) string {
+	if name, ok := n[t]; ok {
+		return name
+	}
+	return TypeName(t)
+}
+
+// lookup returns the name of t in n or registered for it, if any.
+func (n TypeNames) lookup(t ItemType) (string, bool) {
+	if name, ok := n[t]; ok {
+		return name, true
+	}
+	typeMu.RLock()
+	defer typeMu.RUnlock()
+	name, ok := typeNames[t]
+	return name, ok
+}
+
+// Type returns the item type named name in n or, if there is none, the
+// type registered with name. If n gives name to several types, Type
+// returns the smallest of them.
+func (n TypeNames) Type(name string) (ItemType, bool) {
+	found := false
+	var typ ItemType
+	for t, s := range n {
+		if s == name && (!found || t < typ) {
+			typ, found = t, true
+		}
+	}
+	if found {
+		return typ, true
+	}
+	return TypeByName(name)
+}
+
+// String returns the item as Item.String does, preceded by the name of
+// its type if it is a client type named in n:
+//
+//	Ident "x"
+func (n TypeNames) String(i Item) string {
+	name, ok := n[i.Typ]
+	if !ok || i.Typ == EOF || i.Typ == ERROR || i.Typ == WARNING {
+		return i.String()
+	}
+	return name + " " + i.String()
+}
+
+// SetTypeNames names item types for s. The names take precedence over
+// those registered with RegisterTypeName in the error messages of s, in
+// Dump, and in the names returned by s.TypeNames, which lexers can pass to
+// the TypeNames methods that print, compare and serialize items. Types
+// missing from names keep their registered names. SetTypeNames may be
+// called while the scan is running; messages produced before the call
+// use the registered names. The NamedTypes option sets the names before
+// the scan starts. SetTypeNames panics if names gives the same name to two
+// types.
+//
+// Item.String, and so the %v verb, does not know the scanner an item came
+// from and does not use the names; print items with s.TypeNames().String
+// to show them.
+func (s *Scanner) SetTypeNames(names map[ItemType]string) {
+	m := make(TypeNames, len(names))
+	types := make(map[string]ItemType, len(names))
+	for t, name := range names {
+		if u, ok := types[name]; ok {
+			if u > t {
+				t, u = u, t
+			}
+			panic(fmt.Sprintf("scan: SetTypeNames: name %q given to types %d and %d", name, u, t))
+		}
+		types[name] = t
+		m[t] = name
+	}
+	s.typeNames.Store(&m)
+}
+
+// NamedTypes is an option that calls SetTypeNames with names before the
+// scan starts.
+func NamedTypes(names map[ItemType]string) Option {
+	return func(s *Scanner) {
+		s.SetTypeNames(names)
+	}
+}
+
+// TypeNames returns the names set with SetTypeNames, or nil if there are
+// none.
+func (s *Scanner) TypeNames() TypeNames {
+	if p := s.typeNames.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// names returns the type names of s, which sub-scanners share with the
+// scanner that started them.
+func (s *Scanner) names() TypeNames {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s.TypeNames()
+}
@@ -0,0 +1,25 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+func TestNewTypeSpace(t *testing.T) {
+	a := NewTypeSpace(3)
+	b := NewTypeSpace(0)
+	c := NewTypeSpace(2)
+	if a < firstSpace || b < a+3 || c < b {
+		t.Errorf("got overlapping spaces at %d, %d and %d", a, b, c)
+	}
+	if d := NewTypeSpace(1); d < c+2 {
+		t.Errorf("got space at %d, expected it after %d", d, c+2)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("reserving a negative number of types did not panic")
+		}
+	}()
+	NewTypeSpace(-1)
+}
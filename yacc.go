@@ -0,0 +1,71 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "fmt"
+
+// A YaccLexer adapts a Scanner to the lexer interface expected by parsers
+// generated by goyacc,
+//
+//	type yyLexer interface {
+//		Lex(lval *yySymType) int
+//		Error(s string)
+//	}
+//
+// which YaccLexer[yySymType] implements:
+//
+//	lex := &scan.YaccLexer[yySymType]{
+//		Scanner: scan.New(name, input, lexStart),
+//		Tokens:  map[scan.ItemType]int{NUMBER: NUM, IDENTIFIER: IDENT},
+//		Set:     func(lval *yySymType, item scan.Item) { lval.item = item },
+//	}
+//	yyParse(lex)
+type YaccLexer[T any] struct {
+	Scanner *Scanner
+	Tokens  map[ItemType]int      // token codes of the item types
+	Set     func(lval *T, i Item) // stores an item in the semantic value; may be nil
+
+	Last     Item     // the item most recently returned by Lex
+	Errors   []string // messages passed to Error, prefixed with the position of Last
+	Warnings []string // values of skipped warning items, prefixed with their positions
+}
+
+// Lex returns the token code of the next item and stores the item in lval.
+// Items whose types are not in Tokens are returned as the code of their
+// rune if their value is a single rune, which matches character literals
+// such as '+' in the grammar. EOF is returned as 0, the end of the input
+// for goyacc parsers, and an error item is reported with Error and then
+// also ends the input. Warning items are recorded in Warnings and skipped.
+func (l *YaccLexer[T]) Lex(lval *T) int {
+	item := l.Scanner.NextItem()
+	for item.Typ == WARNING {
+		l.Warnings = append(l.Warnings, fmt.Sprintf("%s: %s", l.Scanner.Position(item.Pos), item.Val))
+		item = l.Scanner.NextItem()
+	}
+	l.Last = item
+	if l.Set != nil {
+		l.Set(lval, item)
+	}
+	if code, ok := l.Tokens[item.Typ]; ok {
+		return code
+	}
+	switch item.Typ {
+	case EOF:
+		return 0
+	case ERROR:
+		l.Error(item.Val)
+		return 0
+	}
+	if r := []rune(item.Val); len(r) == 1 {
+		return int(r[0])
+	}
+	l.Error(fmt.Sprintf("no token code for item %v", item))
+	return 0
+}
+
+// Error records a syntax error reported by the parser.
+func (l *YaccLexer[T]) Error(msg string) {
+	l.Errors = append(l.Errors, fmt.Sprintf("%s: %s", l.Scanner.Position(l.Last.Pos), msg))
+}
@@ -0,0 +1,68 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import "testing"
+
+// symType stands in for the yySymType generated by goyacc.
+type symType struct {
+	yys  int
+	item Item
+}
+
+// yyLexer is the interface generated by goyacc.
+type yyLexer interface {
+	Lex(lval *symType) int
+	Error(s string)
+}
+
+const (
+	tokNUM = iota + 57346
+	tokIDENT
+)
+
+func TestYaccLexer(t *testing.T) {
+	lex := &YaccLexer[symType]{
+		Scanner: New("yacc", "(x + 12)\n?", lexStart),
+		Tokens:  map[ItemType]int{INTEGER: tokNUM, IDENTIFIER: tokIDENT},
+		Set:     func(lval *symType, item Item) { lval.item = item },
+	}
+	var l yyLexer = lex
+	expected := []struct {
+		code int
+		val  string
+	}{
+		{'(', "("},
+		{tokIDENT, "x"},
+		{'+', "+"},
+		{tokNUM, "12"},
+		{')', ")"},
+		{0, "lex error"},
+	}
+	for _, e := range expected {
+		var lval symType
+		if code := l.Lex(&lval); code != e.code || lval.item.Val != e.val {
+			t.Errorf("got %d %q, expected %d %q", code, lval.item.Val, e.code, e.val)
+		}
+	}
+	if len(lex.Errors) != 1 || lex.Errors[0] != "yacc:2:1: lex error" {
+		t.Errorf("got errors %q", lex.Errors)
+	}
+}
+
+func TestYaccLexerWarning(t *testing.T) {
+	lex := &YaccLexer[symType]{
+		Scanner: New("yacc", "a\u202Eb c", lexBidi, DetectBidi(WARNING)),
+		Tokens:  map[ItemType]int{IDENTIFIER: tokIDENT},
+	}
+	for _, code := range []int{tokIDENT, tokIDENT, 0} {
+		if got := lex.Lex(new(symType)); got != code {
+			t.Errorf("got %d, expected %d", got, code)
+		}
+	}
+	if len(lex.Errors) != 0 || len(lex.Warnings) != 1 || lex.Warnings[0] != "yacc:1:2: bidirectional control character U+202E at 1:2" {
+		t.Errorf("got errors %q and warnings %q", lex.Errors, lex.Warnings)
+	}
+}